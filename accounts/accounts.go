@@ -0,0 +1,158 @@
+// Package accounts is the bcrypt-backed user store shared by pokecat and
+// pokeBat, so a player has the same username and PlayerID in both games.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrEmptyCredentials = errors.New("username and password cannot be empty")
+	ErrUserNotFound     = errors.New("username not found")
+	ErrWrongPassword    = errors.New("invalid password")
+	ErrUserExists       = errors.New("username already exists")
+)
+
+// User is a registered player as stored in users.json.
+type User struct {
+	Username string `json:"Username"`
+	Password string `json:"PasswordHash"` // Store hashed password
+	PlayerID string `json:"PlayerID"`
+}
+
+// Store reads and writes users.json. The file is re-read on every call so
+// that accounts registered by another process are picked up.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the users file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Authenticate checks a username and password against the stored hash.
+func (s *Store) Authenticate(username, password string) (User, error) {
+	if username == "" || password == "" {
+		return User{}, ErrEmptyCredentials
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return User{}, err
+	}
+
+	for _, user := range users {
+		if user.Username == username {
+			if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+				return User{}, ErrWrongPassword
+			}
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+// Register creates a new account with a fresh PlayerID.
+func (s *Store) Register(username, password string) (User, error) {
+	if username == "" || password == "" {
+		return User{}, ErrEmptyCredentials
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return User{}, err
+	}
+
+	// Check if username already exists
+	for _, user := range users {
+		if user.Username == username {
+			return User{}, ErrUserExists
+		}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	newUser := User{
+		Username: username,
+		Password: string(hashedPassword),
+		PlayerID: uuid.New().String(),
+	}
+	if err := s.save(append(users, newUser)); err != nil {
+		return User{}, err
+	}
+	return newUser, nil
+}
+
+// load reads all users. A missing file means no one has registered yet.
+func (s *Store) load() ([]User, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// save writes to a temporary file and renames it over users.json, so a
+// concurrent reader never sees a half-written file.
+func (s *Store) save(users []User) error {
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".users-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
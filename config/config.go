@@ -0,0 +1,98 @@
+// Package config layers the settings of the servers and clients: the
+// defaults of their flags, an optional JSON config file, environment
+// variables and the command line, each overriding the one before. Every
+// setting is a flag, so -help lists them all.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Load parses args into flags and fills every flag that was not given on
+// the command line from the environment variable <prefix>_<NAME> (the flag
+// name upper-cased, with dashes as underscores) or else from the config
+// file. The file is named by -config or <prefix>_CONFIG and holds a JSON
+// object keyed by flag name, e.g. {"listen": ":9080", "grid-size": 200}.
+func Load(flags *flag.FlagSet, prefix string, args []string) error {
+	file := flags.String("config", "", "JSON config file with settings keyed by flag name (env "+EnvName(prefix, "config")+")")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	onCommandLine := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = true
+	})
+	if !onCommandLine["config"] {
+		*file = os.Getenv(EnvName(prefix, "config"))
+	}
+
+	settings, err := readFile(*file, flags)
+	if err != nil {
+		return err
+	}
+
+	var setErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		if setErr != nil || onCommandLine[f.Name] || f.Name == "config" {
+			return
+		}
+		if value, ok := os.LookupEnv(EnvName(prefix, f.Name)); ok {
+			if err := flags.Set(f.Name, value); err != nil {
+				setErr = fmt.Errorf("%s: %w", EnvName(prefix, f.Name), err)
+			}
+			return
+		}
+		if value, ok := settings[f.Name]; ok {
+			if err := flags.Set(f.Name, value); err != nil {
+				setErr = fmt.Errorf("%s: %s: %w", *file, f.Name, err)
+			}
+		}
+	})
+	return setErr
+}
+
+// EnvName is the environment variable for a flag, e.g. POKECAT_GRID_SIZE.
+func EnvName(prefix, flagName string) string {
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// readFile reads a config file into flag values. Strings are taken as
+// they are and numbers and booleans as written, so durations are strings
+// ("30s") and counts are numbers. Keys that are not flags are an error, so
+// a typo does not silently fall back to the default.
+func readFile(path string, flags *flag.FlagSet) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if flags.Lookup(name) == nil || name == "config" {
+			return nil, fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+		switch value.(type) {
+		case string, json.Number, bool:
+			settings[name] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("%s: %s must be a string, number or boolean", path, name)
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"flag": "file", "env": "file", "file": "file", "count": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_FLAG", "env")
+	t.Setenv("TEST_ENV", "env")
+	t.Setenv("TEST_CONFIG", file)
+
+	// Each setting is named after where its value should come from
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	values := map[string]*string{}
+	for _, name := range []string{"flag", "env", "file", "default"} {
+		values[name] = flags.String(name, "default", "")
+	}
+	count := flags.Int("count", 1, "")
+
+	if err := Load(flags, "TEST", []string{"-flag", "flag"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range values {
+		if *value != name {
+			t.Errorf("-%s = %q, want %q", name, *value, name)
+		}
+	}
+	if *count != 3 {
+		t.Errorf("-count = %d, want 3 from the file", *count)
+	}
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"env.json": `{"listen": "env file"}`, "flag.json": `{"listen": "flag file"}`} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("TEST_CONFIG", filepath.Join(dir, "env.json"))
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	listen := flags.String("listen", "default", "")
+	if err := Load(flags, "TEST", []string{"-config", filepath.Join(dir, "flag.json")}); err != nil {
+		t.Fatal(err)
+	}
+	if *listen != "flag file" {
+		t.Errorf("-listen = %q, want it from the -config file", *listen)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+	}{
+		{name: "unknown key in file", file: `{"lisen": ":9080"}`},
+		{name: "object in file", file: `{"count": {"n": 3}}`},
+		{name: "bad number in file", file: `{"count": "three"}`},
+		{name: "bad number in env", env: "three"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var args []string
+			if test.file != "" {
+				file := filepath.Join(t.TempDir(), "config.json")
+				if err := os.WriteFile(file, []byte(test.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				args = []string{"-config", file}
+			}
+			if test.env != "" {
+				t.Setenv("TEST_COUNT", test.env)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.String("listen", "", "")
+			flags.Int("count", 1, "")
+			if err := Load(flags, "TEST", args); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
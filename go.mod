@@ -2,12 +2,17 @@ module PokemonNetCen
 
 go 1.23.1
 
-require github.com/playwright-community/playwright-go v0.4901.0
+require (
+	github.com/PuerkitoBio/goquery v1.9.3
+	github.com/google/uuid v1.6.0
+	github.com/playwright-community/playwright-go v0.4901.0
+	golang.org/x/crypto v0.31.0
+)
 
 require (
+	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/deckarep/golang-set/v2 v2.7.0 // indirect
 	github.com/go-jose/go-jose/v3 v3.0.3 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/net v0.29.0 // indirect
 )
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package playerdata
+
+import "os"
+
+// lockFile does nothing where flock is not available, so only the Store's
+// own lock applies and the programs sharing a save directory must not
+// write the same save at once.
+func lockFile(f *os.File) error {
+	return nil
+}
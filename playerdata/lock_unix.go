@@ -0,0 +1,14 @@
+//go:build unix
+
+package playerdata
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on f, waiting for any other process
+// holding it. Closing f releases it.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
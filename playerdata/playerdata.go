@@ -0,0 +1,207 @@
+// Package playerdata reads and writes the per-player save files in
+// playerData/<PlayerID>.json that pokecat creates and pokeBat battles with.
+package playerdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"PokemonNetCen/pokemon"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a player has no save file.
+var ErrNotFound = errors.New("player data not found")
+
+// Player is the saved state of a player.
+type Player struct {
+	ID       string            `json:"ID"`
+	Name     string            `json:"Name"`
+	Position [2]int            `json:"Position"`
+	Caught   []pokemon.Pokemon `json:"Caught"`
+	AutoMode bool              `json:"AutoMode"`
+}
+
+// Store is a directory of save files. Both pokecat and pokeBat write to
+// it, so changes go through Update, which re-reads the file first. Every
+// read-change-write holds the save's lock file, <PlayerID>.lock, which is
+// shared by all processes using the directory, so that a change made by
+// one program between the read and the write of another is not lost.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Path returns the save file location for a PlayerID.
+func (s *Store) Path(playerID string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.json", playerID))
+}
+
+// Load reads a player's save. Saves written before caught Pokémon had an
+// InstanceID are given IDs and written back straight away, so every
+// program that loads the save afterwards sees the same IDs.
+func (s *Store) Load(playerID string) (*Player, error) {
+	unlock, err := s.lock(playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	player, err := s.load(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if assignInstanceIDs(player) {
+		if err := s.save(player); err != nil {
+			return nil, err
+		}
+	}
+	return player, nil
+}
+
+// Update applies change to the freshly loaded save and writes it back.
+func (s *Store) Update(playerID string, change func(*Player) error) (*Player, error) {
+	unlock, err := s.lock(playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	player, err := s.load(playerID)
+	if err != nil {
+		return nil, err
+	}
+	assignInstanceIDs(player)
+	if err := change(player); err != nil {
+		return nil, err
+	}
+	if err := s.save(player); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// NewInstanceID returns an ID for a newly caught Pokémon.
+func NewInstanceID() string {
+	return uuid.New().String()
+}
+
+// assignInstanceIDs gives every caught Pokémon without an ID a new one.
+func assignInstanceIDs(player *Player) bool {
+	changed := false
+	for i := range player.Caught {
+		if player.Caught[i].InstanceID == "" {
+			player.Caught[i].InstanceID = NewInstanceID()
+			changed = true
+		}
+	}
+	return changed
+}
+
+// FindCaught returns the index of the caught Pokémon with the given
+// InstanceID, or -1.
+func (p *Player) FindCaught(instanceID string) int {
+	for i := range p.Caught {
+		if p.Caught[i].InstanceID == instanceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// lock takes the Store's lock and the save's lock file and returns the
+// function that releases both.
+func (s *Store) lock(playerID string) (unlock func(), err error) {
+	if playerID == "" || filepath.Base(playerID) != playerID {
+		return nil, fmt.Errorf("invalid PlayerID %q", playerID)
+	}
+
+	s.mu.Lock()
+	defer func() {
+		if err != nil {
+			s.mu.Unlock()
+		}
+	}()
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, playerID+".lock"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking the save of %s: %w", playerID, err)
+	}
+	return func() {
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+func (s *Store) load(playerID string) (*Player, error) {
+	data, err := os.ReadFile(s.Path(playerID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var player Player
+	if err := json.Unmarshal(data, &player); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", s.Path(playerID), err)
+	}
+	return &player, nil
+}
+
+// Save writes a player's save, replacing the old file atomically so that
+// another program reading it never sees a partial write. Use Update to
+// change an existing save.
+func (s *Store) Save(player *Player) error {
+	unlock, err := s.lock(player.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.save(player)
+}
+
+func (s *Store) save(player *Player) error {
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(player, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".save-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path(player.ID))
+}
@@ -0,0 +1,59 @@
+package playerdata
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestUpdateAcrossStores has two Stores on one directory, standing in for
+// pokecat and pokeBat, change the same save at once. Only the lock file is
+// shared between them, so every change must survive.
+func TestUpdateAcrossStores(t *testing.T) {
+	dir := t.TempDir()
+	stores := []*Store{NewStore(dir), NewStore(dir)}
+	if err := stores[0].Save(&Player{ID: "ash", Name: "Ash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const updates = 50
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range updates {
+					_, err := store.Update("ash", func(player *Player) error {
+						player.Position[0]++
+						return nil
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	player, err := stores[1].Load("ash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4 * updates; player.Position[0] != want {
+		t.Errorf("%d of %d updates kept", player.Position[0], want)
+	}
+}
+
+func TestInvalidPlayerID(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, playerID := range []string{"", "../ash", "a/b"} {
+		if _, err := store.Load(playerID); err == nil {
+			t.Errorf("Load(%q) succeeded", playerID)
+		}
+		if err := store.Save(&Player{ID: playerID}); err == nil {
+			t.Errorf("Save with ID %q succeeded", playerID)
+		}
+	}
+}
@@ -2,40 +2,54 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"strings"
-)
 
-const (
-	HOST = "localhost"
-	PORT = "8081"
+	"PokemonNetCen/config"
+	"PokemonNetCen/pokeBat/protocol"
 )
 
 func main() {
-	conn, err := net.Dial("tcp", HOST+":"+PORT)
+	flags := flag.NewFlagSet("pokeBat client", flag.ExitOnError)
+	server := flags.String("server", "localhost:8081", "address of the pokeBat server")
+	if err := config.Load(flags, "POKEBAT_CLIENT", os.Args[1:]); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	conn, err := net.Dial("tcp", *server)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer conn.Close()
 
-	reader := bufio.NewReader(conn)
+	wire := protocol.NewConn(conn)
 	fmt.Println("Connected to PokeBat Game Server")
 
+	// Handshake
+	msg, err := wire.Receive()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := protocol.CheckHello(msg); err != nil {
+		log.Fatal(err)
+	}
+	wire.Send(protocol.NewHello("pokeBat terminal client"))
+
 	// Authentication
 	username := prompt("Enter username: ")
 	password := prompt("Enter password: ")
+	wire.Send(protocol.NewAuth(username, password))
 
-	authData := fmt.Sprintf("%s_%s\n", username, password)
-	conn.Write([]byte(authData))
-
-	response, err := reader.ReadString('\n')
+	msg, err = wire.Receive()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if strings.TrimSpace(response) != "authenticated" {
+	if msg.Type != protocol.TypeResult || !msg.Result.OK {
 		fmt.Println("Authentication failed.")
 		return
 	}
@@ -43,20 +57,47 @@ func main() {
 
 	// Game loop
 	for {
-		message, err := reader.ReadString('\n')
+		msg, err := wire.Receive()
+		if err == io.EOF {
+			fmt.Println("Disconnected from server.")
+			return
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Print(message)
 
-		if strings.Contains(message, "Choose your starting Pokémon:") || strings.Contains(message, "Your turn!") || strings.Contains(message, "Choose a Pokémon to switch to:") {
+		switch msg.Type {
+		case protocol.TypeResult:
+			fmt.Println(msg.Result.Text)
+		case protocol.TypeState:
+			printState(msg.State)
+		case protocol.TypePrompt:
+			fmt.Println(msg.Prompt.Text)
+			for _, option := range msg.Prompt.Options {
+				fmt.Printf("%s: %s\n", option.Value, option.Label)
+			}
 			input := prompt("")
-			conn.Write([]byte(input + "\n"))
+			wire.Send(protocol.NewAction(msg.Prompt.Kind, input))
+		case protocol.TypeEnd:
+			fmt.Println(msg.End.Reason)
+			if msg.End.Won {
+				fmt.Println("Congratulations! You won the battle.")
+			} else {
+				fmt.Println("You lost the battle.")
+			}
 		}
+	}
+}
 
-		if strings.Contains(message, "Congratulations! You won the battle.") || strings.Contains(message, "You lost the battle.") {
-			break
+func printState(state *protocol.State) {
+	fmt.Printf("--- Round %d vs %s ---\n", state.Round, state.Opponent)
+	fmt.Printf("Opponent: %s Lv.%d [%s] HP: %d/%d\n", state.Enemy.Name, state.Enemy.Level, strings.Join(state.Enemy.Elements, "/"), state.Enemy.HP, state.Enemy.MaxHP)
+	for _, pokemon := range state.Team {
+		marker := " "
+		if pokemon.Active {
+			marker = "*"
 		}
+		fmt.Printf("%s %s Lv.%d [%s] HP: %d/%d\n", marker, pokemon.Name, pokemon.Level, strings.Join(pokemon.Elements, "/"), pokemon.HP, pokemon.MaxHP)
 	}
 }
 
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+
+	"PokemonNetCen/config"
+	"PokemonNetCen/pokemon"
+)
+
+// Config holds the server settings. Each one is a flag that can also be
+// set with a POKEBAT_<FLAG> environment variable or in a -config file.
+type Config struct {
+	Listen        string
+	DataDir       string
+	UsersFile     string
+	PlayerDataDir string
+}
+
+func loadConfig(args []string) (Config, error) {
+	var c Config
+	flags := flag.NewFlagSet("pokeBat", flag.ExitOnError)
+	flags.StringVar(&c.Listen, "listen", "localhost:8081", "address to listen on")
+	flags.StringVar(&c.DataDir, "data", "../../data", "data directory holding "+pokemon.PokedexFile)
+	flags.StringVar(&c.UsersFile, "users", "../../pokecat/server/users.json", "account store shared with pokecat")
+	flags.StringVar(&c.PlayerDataDir, "player-data", "../../playerData", "directory of the pokecat saves")
+
+	err := config.Load(flags, "POKEBAT", args)
+	return c, err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"PokemonNetCen/pokeBat/protocol"
+	"PokemonNetCen/pokemon"
+)
+
+// Lobby queues authenticated players and pairs them into battles. Every
+// battle runs in its own goroutine so the server can host many at once.
+type Lobby struct {
+	pokedex *pokemon.Pokedex
+	queue   chan *Player
+}
+
+func newLobby(pokedex *pokemon.Pokedex) *Lobby {
+	return &Lobby{
+		pokedex: pokedex,
+		queue:   make(chan *Player),
+	}
+}
+
+// join puts the player in the matchmaking queue.
+func (l *Lobby) join(player *Player) {
+	log.Printf("%s joined the lobby", player.Name)
+	player.notify("Waiting for another player to connect...")
+	l.queue <- player
+}
+
+// run pairs queued players in arrival order.
+func (l *Lobby) run() {
+	for {
+		player1 := <-l.queue
+		player2 := <-l.queue
+		go l.startBattle(player1, player2)
+	}
+}
+
+// startBattle deals both players a team, lets them choose their starting
+// Pokémon and runs the battle. Afterwards both players go back to the
+// lobby or leave.
+func (l *Lobby) startBattle(player1, player2 *Player) {
+	log.Printf("Starting battle: %s vs %s", player1.Name, player2.Name)
+	players := []*Player{player1, player2}
+
+	for i, player := range players {
+		opponent := players[1-i]
+		player.notify("Opponent found: %s", opponent.Name)
+	}
+
+	// Both players build their team and choose their starting Pokémon at the same time
+	errs := make([]error, len(players))
+	var wg sync.WaitGroup
+	for i, player := range players {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = buildTeam(player, l.pokedex); errs[i] == nil {
+				player.ActivePokemonIndex, errs[i] = chooseStartingPokemon(player)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errs[0] != nil || errs[1] != nil {
+		for i, player := range players {
+			if errs[i] != nil {
+				l.leave(player)
+			} else {
+				player.notify("Your opponent left before the battle started.")
+				go l.join(player)
+			}
+		}
+		return
+	}
+
+	// Start the battle
+	seed := time.Now().UnixNano()
+	log.Printf("Battle seed: %d", seed)
+	pokemonBattle(&Battle{
+		Player1: player1,
+		Player2: player2,
+		Rng:     rand.New(rand.NewSource(seed)),
+		Pokedex: l.pokedex,
+	})
+
+	for _, player := range players {
+		recordProgress(player, l.pokedex)
+		go l.playAgain(player)
+	}
+}
+
+// playAgain asks a player who finished a battle whether to queue again.
+func (l *Lobby) playAgain(player *Player) {
+	options := []protocol.Option{
+		{Value: "yes", Label: "Queue for another battle"},
+		{Value: "no", Label: "Leave"},
+	}
+	answer, err := player.ask(protocol.PromptPlayAgain, "Play again? (yes/no)", options)
+	if err == nil && strings.EqualFold(answer, "yes") {
+		l.join(player)
+		return
+	}
+	if err == nil {
+		player.notify("Goodbye!")
+	}
+	l.leave(player)
+}
+
+// leave closes the player's connection.
+func (l *Lobby) leave(player *Player) {
+	log.Printf("%s left the lobby", player.Name)
+	if err := player.Conn.Close(); err != nil {
+		log.Printf("Error closing connection for %s: %v\n", player.Name, err)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"PokemonNetCen/pokeBat/protocol"
+	"PokemonNetCen/pokemon"
+)
+
+const MOVESET_SIZE = 4
+
+// Struggle is used when a Pokémon has run out of PP on every move.
+var struggle = pokemon.Move{Name: "Struggle", Power: "50", Acc: 0, Description: "Used only when no PP is left."}
+
+// Moves handed out to species whose crawled move list is empty,
+// so that every Pokémon has at least one move of its own element.
+var defaultMoves = map[string]pokemon.Move{
+	"normal":   {Name: "Tackle", Element: "normal", Power: "40", Acc: 100, PP: 35},
+	"fire":     {Name: "Ember", Element: "fire", Power: "40", Acc: 100, PP: 25},
+	"water":    {Name: "Water Gun", Element: "water", Power: "40", Acc: 100, PP: 25},
+	"grass":    {Name: "Vine Whip", Element: "grass", Power: "45", Acc: 100, PP: 25},
+	"electric": {Name: "Thunder Shock", Element: "electric", Power: "40", Acc: 100, PP: 30},
+	"ice":      {Name: "Powder Snow", Element: "ice", Power: "40", Acc: 100, PP: 25},
+	"fighting": {Name: "Karate Chop", Element: "fighting", Power: "50", Acc: 100, PP: 25},
+	"poison":   {Name: "Poison Sting", Element: "poison", Power: "15", Acc: 100, PP: 35},
+	"ground":   {Name: "Mud-Slap", Element: "ground", Power: "20", Acc: 100, PP: 10},
+	"flying":   {Name: "Gust", Element: "flying", Power: "40", Acc: 100, PP: 35},
+	"psychic":  {Name: "Confusion", Element: "psychic", Power: "50", Acc: 100, PP: 25},
+	"bug":      {Name: "Bug Bite", Element: "bug", Power: "60", Acc: 100, PP: 20},
+	"rock":     {Name: "Rock Throw", Element: "rock", Power: "50", Acc: 90, PP: 15},
+	"ghost":    {Name: "Lick", Element: "ghost", Power: "30", Acc: 100, PP: 30},
+	"dragon":   {Name: "Twister", Element: "dragon", Power: "40", Acc: 100, PP: 20},
+	"dark":     {Name: "Bite", Element: "dark", Power: "60", Acc: 100, PP: 25},
+	"steel":    {Name: "Metal Claw", Element: "steel", Power: "50", Acc: 95, PP: 35},
+	"fairy":    {Name: "Fairy Wind", Element: "fairy", Power: "40", Acc: 100, PP: 30},
+}
+
+// Elements whose moves hit with Sp_Attack against Sp_Defense. The crawled
+// moves carry no physical/special category, so it is derived from the element.
+var specialElements = map[string]bool{
+	"fire": true, "water": true, "grass": true, "electric": true, "ice": true,
+	"psychic": true, "dragon": true, "dark": true, "fairy": true,
+}
+
+// BattlePokemon is a Pokémon taking part in a battle together with the
+// moves it can use and their remaining PP. The embedded Pokemon keeps its
+// base stats; BattleStats are the actual stats at its level.
+type BattlePokemon struct {
+	pokemon.Pokemon
+	BattleStats pokemon.Stats
+	CurrentHP   int
+	Moveset     []pokemon.Move
+	PP          []int
+	TypeChart   pokemon.TypeChart
+
+	// What the Pokémon gained in this battle, recorded on its save afterwards
+	GainedEV         float64
+	GainedExperience int
+}
+
+func newBattlePokemon(p pokemon.Pokemon) BattlePokemon {
+	if p.Level <= 0 {
+		p.Level = DEFAULT_LEVEL
+	}
+
+	moveset := pickMoveset(p)
+	pp := make([]int, len(moveset))
+	for i, move := range moveset {
+		pp[i] = move.PP
+	}
+
+	stats := p.ActualStats()
+	return BattlePokemon{
+		Pokemon:     p,
+		BattleStats: stats,
+		CurrentHP:   stats.HP,
+		Moveset:     moveset,
+		PP:          pp,
+		TypeChart:   p.TypeChart(),
+	}
+}
+
+// refreshStats recalculates the battle stats and type chart after the
+// Pokémon's Level, EV or species changed. Any gain in max HP is added to
+// its current HP.
+func (bp *BattlePokemon) refreshStats() {
+	stats := bp.ActualStats()
+	if gained := stats.HP - bp.BattleStats.HP; gained > 0 {
+		bp.CurrentHP += gained
+	}
+	bp.BattleStats = stats
+	bp.TypeChart = bp.Pokemon.TypeChart()
+}
+
+// pickMoveset chooses up to MOVESET_SIZE moves the Pokémon knows at its
+// level from the species' move list, preferring moves that deal damage.
+func pickMoveset(p pokemon.Pokemon) []pokemon.Move {
+	var damaging, status []pokemon.Move
+	for _, i := range rand.Perm(len(p.Moves)) {
+		if !p.Moves[i].KnownAt(p.Level) {
+			continue
+		}
+		if p.Moves[i].BasePower() > 0 {
+			damaging = append(damaging, p.Moves[i])
+		} else {
+			status = append(status, p.Moves[i])
+		}
+	}
+	moveset := append(damaging, status...)
+
+	if len(damaging) == 0 {
+		// Fall back to a basic move for each of the Pokémon's elements
+		moveset = nil
+		for _, element := range p.Elements {
+			if move, ok := defaultMoves[element]; ok {
+				moveset = append(moveset, move)
+			}
+		}
+		if len(moveset) == 0 {
+			moveset = append(moveset, defaultMoves["normal"])
+		}
+	}
+
+	if len(moveset) > MOVESET_SIZE {
+		moveset = moveset[:MOVESET_SIZE]
+	}
+	return moveset
+}
+
+// hasPP reports whether any move still has PP left.
+func (bp *BattlePokemon) hasPP() bool {
+	for _, pp := range bp.PP {
+		if pp > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseMove asks the player which move their active Pokémon should use.
+// It returns -1 when the Pokémon has to use Struggle.
+func chooseMove(player *Player) (int, error) {
+	active := &player.Pokemons[player.ActivePokemonIndex]
+	if !active.hasPP() {
+		player.notify("%s has no PP left!", active.Name)
+		return -1, nil
+	}
+
+	options := make([]protocol.Option, len(active.Moveset))
+	for i, move := range active.Moveset {
+		options[i] = protocol.Option{
+			Value: strconv.Itoa(i + 1),
+			Label: fmt.Sprintf("%s (%s, Power: %s, Acc: %d%%, PP: %d/%d)", move.Name, move.Element, powerLabel(move), move.Acc, active.PP[i], move.PP),
+		}
+	}
+
+	for {
+		choice, err := player.ask(protocol.PromptMove, "Choose a move:", options)
+		if err != nil {
+			return 0, err
+		}
+		index, err := strconv.Atoi(choice)
+		if err == nil && index >= 1 && index <= len(active.Moveset) && active.PP[index-1] > 0 {
+			return index - 1, nil
+		}
+		player.reject("Invalid choice. Please choose a move with PP left.")
+	}
+}
+
+func powerLabel(move pokemon.Move) string {
+	if power := move.BasePower(); power > 0 {
+		return strconv.Itoa(power)
+	}
+	return "—"
+}
+
+// moveHits rolls the move's accuracy. Moves without an accuracy value never miss.
+func moveHits(move pokemon.Move) bool {
+	if move.Acc <= 0 {
+		return true
+	}
+	return rand.Intn(100) < move.Acc
+}
+
+// calculateDamage applies the move's power to the attacker's and defender's
+// stats using the standard damage formula at the attacker's level. It also returns
+// the type multiplier so the caller can report how effective the move was.
+func calculateDamage(attacker, defender *BattlePokemon, move pokemon.Move) (int, float64) {
+	power := move.BasePower()
+	if power == 0 {
+		return 0, 1
+	}
+
+	attack, defense := attacker.BattleStats.Attack, defender.BattleStats.Defense
+	if specialElements[move.Element] {
+		attack, defense = attacker.BattleStats.SpAttack, defender.BattleStats.SpDefense
+	}
+	if defense < 1 {
+		defense = 1
+	}
+
+	damage := float64((2*attacker.Level/5+2)*power*attack/defense)/50 + 2
+
+	// Same-type attack bonus and the defender's weakness or resistance
+	effectiveness := defender.TypeChart.Multiplier(move.Element)
+	damage *= attacker.SameTypeBonus(move.Element) * effectiveness
+
+	// Random spread between 85% and 100%
+	damage *= float64(85+rand.Intn(16)) / 100
+
+	return int(damage), effectiveness
+}
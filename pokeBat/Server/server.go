@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -9,130 +9,64 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"PokemonNetCen/accounts"
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokeBat/protocol"
+	"PokemonNetCen/pokemon"
 )
 
 const (
-	HOST         = "localhost"
-	PORT         = "8081"
-	TYPE         = "tcp"
-	MIN_PLAYERS  = 2
-	POKEDEX_FILE = "../assests/pokedex.json"
-	USER_FILE    = "../assests/user.json"
+	TYPE        = "tcp"
+	MIN_PLAYERS = 2
 )
 
-type User struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
-
-type Pokemon struct {
-	Name     string   `json:"Name"`
-	Elements []string `json:"Elements"`
-	EV       int      `json:"EV"`
-	Stats    Stats    `json:"Stats"`
-	Profile  Profile  `json:"Profile"`
-	Damage   []Damage `json:"DamegeWhenAttacked"`
-}
-
-type Stats struct {
-	HP         int `json:"HP"`
-	Attack     int `json:"Attack"`
-	Defense    int `json:"Defense"`
-	Speed      int `json:"Speed"`
-	Sp_Attack  int `json:"Sp_Attack"`
-	Sp_Defense int `json:"Sp_Defense"`
-}
-
-type Profile struct {
-	Height      float64 `json:"Height"`
-	Weight      float64 `json:"Weight"`
-	CatchRate   int     `json:"CatchRate"`
-	GenderRatio struct {
-		MaleRatio   float64 `json:"MaleRatio"`
-		FemaleRatio float64 `json:"FemaleRatio"`
-	} `json:"GenderRatio"`
-	EggGroup   string `json:"EggGroup"`
-	HatchSteps int    `json:"HatchSteps"`
-	Abilities  string `json:"Abilities"`
-}
-
-type Damage struct {
-	Element     string  `json:"Element"`
-	Coefficient float64 `json:"Coefficient"`
-}
-
 type Player struct {
 	Conn               net.Conn
+	Wire               *protocol.Conn // Message encoder/decoder on Conn
 	Name               string
-	Pokemons           []Pokemon
+	PlayerID           string
+	Pokemons           []BattlePokemon
 	ActivePokemonIndex int
 }
 
 type Battle struct {
 	Player1 *Player
 	Player2 *Player
-	Turn    int // 0 for Player1, 1 for Player2
-}
-
-var users []User
-
-// Function to load users from a JSON file
-func loadUsers(filename string) ([]User, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var userData struct {
-		Users []User `json:"users"`
-	}
-	err = json.NewDecoder(file).Decode(&userData)
-	if err != nil {
-		return nil, err
-	}
-
-	return userData.Users, nil
+	Round   int
+	Rng     *rand.Rand       // Breaks speed ties, seeded per battle
+	Pokedex *pokemon.Pokedex // Looks up evolutions
 }
 
-// Function to load Pokémon from a JSON file
-func loadPokemons(filename string) ([]Pokemon, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+var accountStore *accounts.Store // Account store shared with pokecat
 
-	var pokemons []Pokemon
-	err = json.NewDecoder(file).Decode(&pokemons)
+func main() {
+	cfg, err := loadConfig(os.Args[1:])
 	if err != nil {
-		return nil, err
+		log.Fatal("Invalid configuration: ", err)
 	}
+	accountStore = accounts.NewStore(cfg.UsersFile)
+	playerStore = playerdata.NewStore(cfg.PlayerDataDir)
 
-	return pokemons, nil
-}
-
-func main() {
-	var err error
 	// Start TCP server
-	listener, err := net.Listen("tcp", HOST+":"+PORT)
+	listener, err := net.Listen(TYPE, cfg.Listen)
 	if err != nil {
 		fmt.Println("Error starting server:", err)
 		return
 	}
 	defer listener.Close()
 
-	fmt.Println("Server is listening on", HOST+":"+PORT)
-
-	var players []*Player
+	fmt.Println("Server is listening on", cfg.Listen)
 
-	// Load user data from JSON file
-	users, err = loadUsers(USER_FILE)
+	// Load Pokémon data from file
+	pokedex, err := pokemon.LoadDir(cfg.DataDir)
 	if err != nil {
-		fmt.Println("Error loading user data:", err)
-		return
+		log.Fatal("Error loading Pokémon data:", err)
 	}
 
+	lobby := newLobby(pokedex)
+	go lobby.run()
+
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
@@ -141,190 +75,211 @@ func main() {
 		}
 		fmt.Println("Client connected from", conn.RemoteAddr().String())
 
-		// Authenticate the user
-		if !authenticate(conn) {
-			fmt.Println("Authentication failed. Closing connection.")
-			conn.Write([]byte("Authentication failed\n"))
-			conn.Close()
-			continue
-		}
-
-		// Add player to the list
-		player := &Player{
-			Conn: conn,
-			Name: fmt.Sprintf("Player_%s", conn.RemoteAddr().String()),
-		}
-		players = append(players, player)
-
-		// Check if we have two players
-		if len(players) == 2 {
-			fmt.Println("Two players connected. Starting the game...")
-
-			// Load Pokémon data from file
-			pokemons, err := loadPokemons(POKEDEX_FILE)
-			if err != nil {
-				log.Fatal("Error loading Pokémon data:", err)
-			}
-
-			// Randomly assign 3 Pokémon to each player
-			rand.Shuffle(len(pokemons), func(i, j int) {
-				pokemons[i], pokemons[j] = pokemons[j], pokemons[i]
-			})
-
-			// Divide the shuffled list into two sets for each player
-			players[0].Pokemons = pokemons[:3]
-			players[1].Pokemons = pokemons[3:6]
-
-			// Choose starting Pokémon
-			players[0].ActivePokemonIndex = chooseStartingPokemon(players[0])
-			players[1].ActivePokemonIndex = chooseStartingPokemon(players[1])
-
-			// Start the battle
-			pokemonBattle(&Battle{Player1: players[0], Player2: players[1]})
-			break // Exit loop after starting the battle
-		} else {
-			fmt.Println("Waiting for another player to connect...")
-		}
+		go handleConnection(conn, lobby)
 	}
 }
 
-func authenticate(conn net.Conn) bool {
-	// Read authentication data from the connection
-	authData := readFromConn(conn)
-	parts := strings.Split(authData, "_")
-
-	// Check if the format is correct
-	if len(parts) != 2 {
-		log.Printf("Authentication data format error: expected 2 parts, got %d", len(parts))
-		return false
+// handleConnection authenticates a new client and puts it in the lobby queue.
+func handleConnection(conn net.Conn, lobby *Lobby) {
+	wire := protocol.NewConn(conn)
+	user, err := authenticate(wire)
+	if err != nil {
+		fmt.Println("Authentication failed. Closing connection:", err)
+		wire.Send(protocol.NewResult(false, "Authentication failed"))
+		conn.Close()
+		return
 	}
 
-	username := parts[0]
-	receivedPassword := parts[1]
+	lobby.join(&Player{Conn: conn, Wire: wire, Name: user.Username, PlayerID: user.PlayerID})
+}
 
-	// Load users from the JSON file
-	users, err := loadUsers(USER_FILE)
+// authenticate exchanges hellos with the client, then checks its
+// credentials against the shared account store.
+func authenticate(wire *protocol.Conn) (accounts.User, error) {
+	if err := wire.Send(protocol.NewHello("pokeBat server")); err != nil {
+		return accounts.User{}, err
+	}
+	msg, err := wire.Receive()
 	if err != nil {
-		log.Printf("Error loading users: %v", err)
-		return false
+		return accounts.User{}, err
+	}
+	if err := protocol.CheckHello(msg); err != nil {
+		return accounts.User{}, err
 	}
 
-	// Check each user for a match
-	for _, user := range users {
-		if user.Username == username && user.Password == receivedPassword {
-			log.Println("Authentication successful")
-			conn.Write([]byte("authenticated\n"))
-			return true
-		}
+	msg, err = wire.Receive()
+	if err != nil {
+		return accounts.User{}, err
+	}
+	if msg.Type != protocol.TypeAuth {
+		return accounts.User{}, fmt.Errorf("expected auth, got %s", msg.Type)
 	}
 
-	log.Println("Authentication failed: no matching user found")
-	return false
+	user, err := accountStore.Authenticate(msg.Auth.Username, msg.Auth.Password)
+	if err != nil {
+		return accounts.User{}, err
+	}
+	log.Println("Authentication successful:", user.Username)
+	return user, wire.Send(protocol.NewResult(true, "authenticated"))
 }
 
-func readFromConn(conn net.Conn) string {
-	buffer := make([]byte, 1024)
-	n, err := conn.Read(buffer)
-	if err != nil {
-		log.Println("Error reading:", err)
-		return ""
+// notify sends a line of battle narration to the player.
+func (p *Player) notify(format string, args ...any) {
+	p.Wire.Send(protocol.NewResult(true, fmt.Sprintf(format, args...)))
+}
+
+// reject tells the player their last answer was not accepted.
+func (p *Player) reject(text string) {
+	p.Wire.Send(protocol.NewResult(false, text))
+}
+
+// ask sends a prompt and waits for the player's answer to it. Malformed
+// messages and answers to other prompts are rejected and the prompt is
+// sent again; only a broken connection returns an error.
+func (p *Player) ask(kind, text string, options []protocol.Option) (string, error) {
+	for {
+		if err := p.Wire.Send(protocol.NewPrompt(kind, text, options)); err != nil {
+			return "", err
+		}
+
+		msg, err := p.Wire.Receive()
+		if errors.Is(err, protocol.ErrMalformed) {
+			p.reject(err.Error())
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if msg.Type != protocol.TypeAction || msg.Action.Prompt != kind {
+			p.reject(fmt.Sprintf("Expected an answer to the %s prompt.", kind))
+			continue
+		}
+		return strings.TrimSpace(msg.Action.Choice), nil
 	}
-	return strings.TrimSpace(string(buffer[:n]))
 }
 
-func chooseStartingPokemon(player *Player) int {
-	// Display all available Pokémon options
+func chooseStartingPokemon(player *Player) (int, error) {
+	// Offer all available Pokémon as options
+	options := make([]protocol.Option, len(player.Pokemons))
 	for i, pokemon := range player.Pokemons {
-		player.Conn.Write([]byte(fmt.Sprintf("%d: %s\n", i+1, pokemon.Name)))
+		options[i] = protocol.Option{Value: strconv.Itoa(i + 1), Label: pokemon.Name}
 	}
 
-	player.Conn.Write([]byte("Choose your starting Pokémon:\n"))
-
 	for {
-		choice := readFromConn(player.Conn)
+		choice, err := player.ask(protocol.PromptStarter, "Choose your starting Pokémon:", options)
+		if err != nil {
+			return 0, err
+		}
 		index, err := strconv.Atoi(choice)
 		if err == nil && index >= 1 && index <= len(player.Pokemons) {
-			return index - 1
+			return index - 1, nil
 		}
-		player.Conn.Write([]byte("Invalid choice. Please choose a valid Pokémon.\n"))
+		player.reject("Invalid choice. Please choose a valid Pokémon.")
 	}
 }
 
 func pokemonBattle(battle *Battle) {
-	// Start with player1's turn
-	currentPlayer := battle.Player1
-	opponent := battle.Player2
-
 	for {
-		// Prompt current player for action
-		currentPlayer.Conn.Write([]byte("Your turn! Choose an action: attack, switch, or surrender\n"))
-		action := readFromConn(currentPlayer.Conn)
-
-		switch action {
-		case "attack":
-			performAttack(currentPlayer, opponent)
-		case "switch":
-			switchPokemon(currentPlayer)
-		case "surrender":
-			endBattle(battle, opponent)
+		battle.Round++
+		sendState(battle)
+
+		// Both players choose their action at the same time
+		actions := collectActions(battle)
+		orderActions(battle, actions)
+
+		if resolveRound(battle, actions) {
 			return
-		default:
-			currentPlayer.Conn.Write([]byte("Invalid action. Please choose attack, switch, or surrender.\n"))
-			continue
 		}
+	}
+}
 
-		// Check if opponent's Pokémon is defeated
-		if opponent.Pokemons[opponent.ActivePokemonIndex].Stats.HP <= 0 {
-			// Switch to next available Pokémon
-			nextAvailable := false
-			for i, pokemon := range opponent.Pokemons {
-				if pokemon.Stats.HP > 0 {
-					opponent.ActivePokemonIndex = i
-					nextAvailable = true
-					break
-				}
+// resolveRound carries out the ordered actions of a round and reports
+// whether the battle is over.
+func resolveRound(battle *Battle, actions []Action) bool {
+	for _, action := range actions {
+		player, opponent := action.Player, action.Opponent
+
+		switch action.Kind {
+		case ACTION_SURRENDER:
+			if action.Disconnected {
+				endBattle(battle, opponent, "Your opponent disconnected.")
+			} else {
+				endBattle(battle, opponent, fmt.Sprintf("%s surrendered.", player.Name))
+			}
+			return true
+		case ACTION_SWITCH:
+			player.ActivePokemonIndex = action.SwitchIndex
+			active := player.Pokemons[player.ActivePokemonIndex].Name
+			player.notify("Switched to %s.", active)
+			opponent.notify("%s switched to %s.", player.Name, active)
+		case ACTION_ATTACK:
+			// Only the Pokémon that chose the move uses it: one that fainted
+			// earlier this round, or was replaced after fainting, does not move
+			if player.ActivePokemonIndex != action.PokemonIndex || player.Pokemons[action.PokemonIndex].CurrentHP <= 0 {
+				continue
 			}
-			if !nextAvailable {
+			performAttack(battle, player, opponent, action.MoveIndex)
+
+			// Check if opponent's Pokémon is defeated
+			if !replaceFainted(opponent) {
 				// No Pokémon left, opponent loses
-				endBattle(battle, currentPlayer)
-				return
+				endBattle(battle, player, fmt.Sprintf("%s has no Pokémon left.", opponent.Name))
+				return true
 			}
 		}
+	}
+	return false
+}
+
+// replaceFainted sends out the player's next healthy Pokémon if the active
+// one has fainted. It returns false when the player has none left.
+func replaceFainted(player *Player) bool {
+	fainted := player.Pokemons[player.ActivePokemonIndex]
+	if fainted.CurrentHP > 0 {
+		return true
+	}
 
-		// Switch turns
-		if currentPlayer == battle.Player1 {
-			currentPlayer = battle.Player2
-			opponent = battle.Player1
-		} else {
-			currentPlayer = battle.Player1
-			opponent = battle.Player2
+	// Switch to next available Pokémon
+	for i, pokemon := range player.Pokemons {
+		if pokemon.CurrentHP > 0 {
+			player.ActivePokemonIndex = i
+			player.notify("%s fainted! Go, %s!", fainted.Name, pokemon.Name)
+			return true
 		}
 	}
+	return false
 }
 
-func performAttack(attacker, defender *Player) {
+func performAttack(battle *Battle, attacker, defender *Player, moveIndex int) {
 	// Get the active Pokémon for both players
 	attackPokemon := &attacker.Pokemons[attacker.ActivePokemonIndex]
 	defendPokemon := &defender.Pokemons[defender.ActivePokemonIndex]
 
-	// Randomly choose between normal attack and special attack
-	isSpecial := rand.Intn(2) == 0
+	// Use the chosen move, or Struggle when no PP is left
+	move := struggle
+	if moveIndex >= 0 {
+		move = attackPokemon.Moveset[moveIndex]
+		attackPokemon.PP[moveIndex]--
+	}
 
-	var damage int
-	if isSpecial {
-		// Calculate damage for special attack using Sp_Attack
-		damage = (attackPokemon.Stats.Sp_Attack - defendPokemon.Stats.Sp_Defense)
-	} else {
-		// Calculate damage for normal attack using Attack
-		damage = (attackPokemon.Stats.Attack - defendPokemon.Stats.Defense)
+	used := fmt.Sprintf("%s used %s!", attackPokemon.Name, move.Name)
+	if !moveHits(move) {
+		attacker.notify("%s But it missed!", used)
+		defender.notify("%s's %s But it missed!", attacker.Name, used)
+		return
+	}
+	if move.BasePower() == 0 {
+		attacker.notify("%s But nothing happened.", used)
+		defender.notify("%s's %s But nothing happened.", attacker.Name, used)
+		return
 	}
 
-	// Apply elemental damage coefficient from the defender's damage when attacked
-	for _, damageInfo := range defendPokemon.Damage {
-		if damageInfo.Element == attackPokemon.Elements[0] { // Assuming the first element is used for the attack
-			damage = int(float64(damage) * damageInfo.Coefficient)
-			break
-		}
+	damage, effectiveness := calculateDamage(attackPokemon, defendPokemon, move)
+	if effectiveness == 0 {
+		noEffect := fmt.Sprintf("It doesn't affect %s...", defendPokemon.Name)
+		attacker.notify("%s %s", used, noEffect)
+		defender.notify("%s's %s %s", attacker.Name, used, noEffect)
+		return
 	}
 
 	// Ensure minimum damage is 1
@@ -333,58 +288,62 @@ func performAttack(attacker, defender *Player) {
 	}
 
 	// Update the defender's HP
-	defendPokemon.Stats.HP -= damage
-	if defendPokemon.Stats.HP < 0 {
-		defendPokemon.Stats.HP = 0
+	defendPokemon.CurrentHP -= damage
+	if defendPokemon.CurrentHP < 0 {
+		defendPokemon.CurrentHP = 0
 	}
 
 	// Send messages to both players
-	attacker.Conn.Write([]byte(fmt.Sprintf("You attacked %s's %s for %d damage.\n", defender.Name, defendPokemon.Name, damage)))
-	defender.Conn.Write([]byte(fmt.Sprintf("Your %s was attacked for %d damage.\n", defendPokemon.Name, damage)))
+	attacker.notify("%s You attacked %s's %s for %d damage.", used, defender.Name, defendPokemon.Name, damage)
+	defender.notify("%s's %s Your %s was attacked for %d damage.", attacker.Name, used, defendPokemon.Name, damage)
+	if message := pokemon.EffectivenessMessage(effectiveness); message != "" {
+		attacker.notify("%s", message)
+		defender.notify("%s", message)
+	}
 
 	// Check if the defender's Pokémon is defeated
-	if defendPokemon.Stats.HP <= 0 {
+	if defendPokemon.CurrentHP <= 0 {
 		// Increment EV for the attacking Pokémon
 		attackPokemon.EV += 1
-		attacker.Conn.Write([]byte(fmt.Sprintf("%s has defeated %s and gained 1 EV point!\n", attackPokemon.Name, defendPokemon.Name)))
+		attackPokemon.GainedEV += 1
+		attacker.notify("%s has defeated %s and gained 1 EV point!", attackPokemon.Name, defendPokemon.Name)
 
-		// Update stats based on the new EV
-		updateStats(attackPokemon)
+		// Award experience, which may level up or evolve the attacker
+		progress := attackPokemon.GainExperience(pokemon.ExperienceYield(&defendPokemon.Pokemon), battle.Pokedex)
+		attackPokemon.GainedExperience += progress.Gained
+		announceProgress(attacker, defender, progress)
 
-		// Update the pokedex.json file
-		updatePokedex(attacker.Pokemons)
+		// Update stats based on the new EV, level and species
+		attackPokemon.refreshStats()
 	}
 }
 
-func switchPokemon(player *Player) {
-	// Notify the player to choose a Pokémon to switch to
-	player.Conn.Write([]byte("Choose a Pokémon to switch to:\n"))
-
-	// List available Pokémon with their HP
-	for i, pokemon := range player.Pokemons {
-		player.Conn.Write([]byte(fmt.Sprintf("%d: %s (HP: %d)\n", i+1, pokemon.Name, pokemon.Stats.HP)))
+// announceProgress tells the attacker how much experience their Pokémon
+// gained and both players about level-ups and evolutions.
+func announceProgress(attacker, defender *Player, progress pokemon.Progress) {
+	active := &attacker.Pokemons[attacker.ActivePokemonIndex]
+	name := active.Name
+	if progress.Evolved() {
+		name = progress.EvolvedFrom[0]
 	}
 
-	// Loop until a valid choice is made
-	for {
-		choice := readFromConn(player.Conn) // Read the player's choice
-		index, err := strconv.Atoi(choice)  // Convert choice to integer
-
-		// Check if the choice is valid
-		if err == nil && index >= 1 && index <= len(player.Pokemons) && player.Pokemons[index-1].Stats.HP > 0 {
-			player.ActivePokemonIndex = index - 1 // Update the active Pokémon index
-			player.Conn.Write([]byte(fmt.Sprintf("Switched to %s.\n", player.Pokemons[player.ActivePokemonIndex].Name)))
-			return // Exit the function after a successful switch
+	attacker.notify("%s gained %d experience points!", name, progress.Gained)
+	if progress.LeveledUp() {
+		attacker.notify("%s grew to level %d!", name, progress.NewLevel)
+	}
+	for i, from := range progress.EvolvedFrom {
+		to := active.Name
+		if i+1 < len(progress.EvolvedFrom) {
+			to = progress.EvolvedFrom[i+1]
 		}
-
-		// Notify the player of an invalid choice
-		player.Conn.Write([]byte("Invalid choice. Please choose a valid Pokémon.\n"))
+		attacker.notify("What? %s is evolving! Congratulations! Your %s evolved into %s!", from, from, to)
+		defender.notify("%s's %s evolved into %s!", attacker.Name, from, to)
 	}
 }
 
-func endBattle(battle *Battle, winner *Player) {
+func endBattle(battle *Battle, winner *Player, reason string) {
 	// Notify the winner
-	winner.Conn.Write([]byte("Congratulations! You won the battle.\n"))
+	winner.Wire.Send(protocol.NewEnd(true, reason))
 
 	// Determine the loser
 	loser := battle.Player1
@@ -393,35 +352,31 @@ func endBattle(battle *Battle, winner *Player) {
 	}
 
 	// Notify the loser
-	loser.Conn.Write([]byte("You lost the battle.\n"))
-
-	// Close connections
-	if err := battle.Player1.Conn.Close(); err != nil {
-		log.Printf("Error closing connection for %s: %v\n", battle.Player1.Name, err)
-	}
-	if err := battle.Player2.Conn.Close(); err != nil {
-		log.Printf("Error closing connection for %s: %v\n", battle.Player2.Name, err)
-	}
+	loser.Wire.Send(protocol.NewEnd(false, reason))
 }
 
-func updateStats(pokemon *Pokemon) {
-	// Recalculate attributes (except Speed and Damage When Attacked)
-	pokemon.Stats.HP = int(float64(pokemon.Stats.HP) * (1 + float64(pokemon.EV)/100))
-	pokemon.Stats.Attack = int(float64(pokemon.Stats.Attack) * (1 + float64(pokemon.EV)/100))
-	pokemon.Stats.Defense = int(float64(pokemon.Stats.Defense) * (1 + float64(pokemon.EV)/100))
-	pokemon.Stats.Sp_Attack = int(float64(pokemon.Stats.Sp_Attack) * (1 + float64(pokemon.EV)/100))
-	pokemon.Stats.Sp_Defense = int(float64(pokemon.Stats.Sp_Defense) * (1 + float64(pokemon.EV)/100))
-}
+// sendState shows both players their team and the opponent's active Pokémon.
+func sendState(battle *Battle) {
+	for _, pair := range [][2]*Player{{battle.Player1, battle.Player2}, {battle.Player2, battle.Player1}} {
+		player, opponent := pair[0], pair[1]
 
-func updatePokedex(pokemons []Pokemon) {
-	file, err := os.OpenFile("pokedex.json", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		log.Fatalf("Error opening pokedex.json: %v", err)
+		state := protocol.State{Round: battle.Round, Opponent: opponent.Name}
+		for i := range player.Pokemons {
+			state.Team = append(state.Team, pokemonState(&player.Pokemons[i], i == player.ActivePokemonIndex))
+		}
+		state.Enemy = pokemonState(&opponent.Pokemons[opponent.ActivePokemonIndex], true)
+
+		player.Wire.Send(protocol.NewState(state))
 	}
-	defer file.Close()
+}
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(pokemons); err != nil {
-		log.Fatalf("Error encoding pokedex.json: %v", err)
+func pokemonState(bp *BattlePokemon, active bool) protocol.PokemonState {
+	return protocol.PokemonState{
+		Name:     bp.Name,
+		Elements: bp.Elements,
+		Level:    bp.Level,
+		HP:       bp.CurrentHP,
+		MaxHP:    bp.BattleStats.HP,
+		Active:   active,
 	}
 }
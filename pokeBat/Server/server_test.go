@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"PokemonNetCen/pokeBat/protocol"
+	"PokemonNetCen/pokemon"
+)
+
+var testStats = pokemon.Stats{HP: 50, Attack: 50, Defense: 50, Speed: 50, SpAttack: 50, SpDefense: 50}
+
+// newTestPlayer returns a player whose messages go to a buffer.
+func newTestPlayer(name string, team ...pokemon.Pokemon) *Player {
+	player := &Player{Name: name, Wire: protocol.NewConn(&bytes.Buffer{})}
+	for _, p := range team {
+		p.Stats = testStats
+		p.Level = DEFAULT_LEVEL
+		player.Pokemons = append(player.Pokemons, newBattlePokemon(p))
+	}
+	return player
+}
+
+func TestResolveRoundReplacementDoesNotMove(t *testing.T) {
+	tests := []struct {
+		name      string
+		leadHP    int  // HP of player 2's lead before the round
+		wantMoved bool // whether player 2's chosen move is used
+	}{
+		// Player 1 knocks out the lead, whose move must not be used by the
+		// replacement, which only has one move
+		{name: "lead knocked out", leadHP: 1, wantMoved: false},
+		{name: "lead survives", leadHP: 10000, wantMoved: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			player1 := newTestPlayer("Ash", pokemon.Pokemon{Name: "Pikachu", Elements: []string{"electric"}})
+			player2 := newTestPlayer("Gary",
+				pokemon.Pokemon{Name: "Bulbasaur", Elements: []string{"grass", "poison"}},
+				pokemon.Pokemon{Name: "Pikachu", Elements: []string{"electric"}})
+			player2.Pokemons[0].CurrentHP = test.leadHP
+			battle := &Battle{Player1: player1, Player2: player2, Rng: rand.New(rand.NewSource(1))}
+
+			attackerHP := player1.Pokemons[0].CurrentHP
+			leadPP := player2.Pokemons[0].PP[1]
+			replacementPP := player2.Pokemons[1].PP[0]
+			actions := []Action{
+				{Player: player1, Opponent: player2, Kind: ACTION_ATTACK, PokemonIndex: 0, MoveIndex: 0},
+				{Player: player2, Opponent: player1, Kind: ACTION_ATTACK, PokemonIndex: 0, MoveIndex: 1},
+			}
+			if over := resolveRound(battle, actions); over {
+				t.Fatal("battle ended, want it to go on")
+			}
+
+			if got := player2.Pokemons[1].PP[0]; got != replacementPP {
+				t.Errorf("replacement PP = %d, want %d", got, replacementPP)
+			}
+			moved := player1.Pokemons[0].CurrentHP < attackerHP
+			if moved != test.wantMoved {
+				t.Errorf("player 2 attacked = %v, want %v", moved, test.wantMoved)
+			}
+			if spent := player2.Pokemons[0].PP[1] < leadPP; spent != test.wantMoved {
+				t.Errorf("lead spent PP = %v, want %v", spent, test.wantMoved)
+			}
+		})
+	}
+}
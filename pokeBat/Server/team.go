@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokeBat/protocol"
+	"PokemonNetCen/pokemon"
+)
+
+const (
+	TEAM_SIZE     = 3
+	DEFAULT_LEVEL = 50 // Level of rental Pokémon and of saves without a level
+)
+
+var playerStore *playerdata.Store // pokecat saves
+
+// buildTeam loads the player's pokecat save and lets them pick TEAM_SIZE
+// of the Pokémon they caught. Players who have not caught enough get
+// random rental Pokémon to fill the remaining slots.
+func buildTeam(player *Player, pokedex *pokemon.Pokedex) error {
+	player.Pokemons = nil
+	player.ActivePokemonIndex = 0
+
+	var caught []pokemon.Pokemon
+	save, err := playerStore.Load(player.PlayerID)
+	switch {
+	case err == nil:
+		caught = save.Caught
+	case errors.Is(err, playerdata.ErrNotFound):
+		log.Printf("%s has no pokecat save", player.Name)
+	default:
+		log.Printf("Error loading save for %s: %v", player.Name, err)
+	}
+
+	if len(caught) <= TEAM_SIZE {
+		// Nothing to choose, the whole collection battles
+		for _, p := range caught {
+			player.Pokemons = append(player.Pokemons, newBattlePokemon(p))
+		}
+	} else if err := pickTeam(player, caught); err != nil {
+		return err
+	}
+
+	if rentals := TEAM_SIZE - len(player.Pokemons); rentals > 0 {
+		player.notify("You have caught %d Pokémon, so %d rental Pokémon join your team.", len(caught), rentals)
+		for range rentals {
+			player.Pokemons = append(player.Pokemons, newBattlePokemon(pokedex.Random(rand.Intn)))
+		}
+	}
+	return nil
+}
+
+// pickTeam asks the player to choose their team one Pokémon at a time.
+func pickTeam(player *Player, caught []pokemon.Pokemon) error {
+	picked := make(map[int]bool)
+	for len(player.Pokemons) < TEAM_SIZE {
+		var options []protocol.Option
+		for i, p := range caught {
+			if !picked[i] {
+				options = append(options, protocol.Option{Value: strconv.Itoa(i + 1), Label: caughtLabel(p)})
+			}
+		}
+
+		text := fmt.Sprintf("Choose Pokémon %d of %d for your team:", len(player.Pokemons)+1, TEAM_SIZE)
+		choice, err := player.ask(protocol.PromptTeam, text, options)
+		if err != nil {
+			return err
+		}
+
+		index, err := strconv.Atoi(choice)
+		if err != nil || index < 1 || index > len(caught) || picked[index-1] {
+			player.reject("Invalid choice. Please choose a Pokémon you have not picked yet.")
+			continue
+		}
+		picked[index-1] = true
+		player.Pokemons = append(player.Pokemons, newBattlePokemon(caught[index-1]))
+	}
+	return nil
+}
+
+func caughtLabel(p pokemon.Pokemon) string {
+	return fmt.Sprintf("%s Lv.%d [%s]", p.Name, p.Level, strings.Join(p.Elements, "/"))
+}
+
+// recordProgress adds what the player's team gained in battle to the
+// Pokémon in their pokecat save. The EV and experience gained are applied
+// to the saved Pokémon as it is now, which levels it up and evolves it the
+// same way the battle did, so progress pokecat saved meanwhile is kept.
+// Rental Pokémon are not saved, and the pokedex itself is never written.
+func recordProgress(player *Player, pokedex *pokemon.Pokedex) {
+	if player.PlayerID == "" {
+		return
+	}
+
+	_, err := playerStore.Update(player.PlayerID, func(save *playerdata.Player) error {
+		for _, bp := range player.Pokemons {
+			if bp.InstanceID == "" || bp.GainedEV == 0 && bp.GainedExperience == 0 {
+				continue
+			}
+			i := save.FindCaught(bp.InstanceID)
+			if i < 0 {
+				continue
+			}
+			saved := &save.Caught[i]
+			if saved.Level <= 0 {
+				saved.Level = DEFAULT_LEVEL
+			}
+			saved.EV += bp.GainedEV
+			saved.GainExperience(bp.GainedExperience, pokedex)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, playerdata.ErrNotFound) {
+		log.Printf("Error saving progress for %s: %v", player.Name, err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
+)
+
+func TestRecordProgressKeepsOtherChanges(t *testing.T) {
+	dex, err := pokemon.New([]pokemon.Pokemon{
+		{Name: "Charmander", Elements: []string{"fire"}, Stats: testStats, NextEvolution: "Charmeleon", EvolutionLevel: 16},
+		{Name: "Charmeleon", Elements: []string{"fire"}, Stats: testStats},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	playerStore = playerdata.NewStore(t.TempDir())
+
+	charmander, _ := dex.ByName("Charmander")
+	charmander.InstanceID = "charmander-1"
+	charmander.Level = 14
+	charmander.Experience = pokemon.ExperienceForLevel(14)
+	if err := playerStore.Save(&playerdata.Player{ID: "ash", Name: "Ash", Caught: []pokemon.Pokemon{charmander}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each gain alone stays below level 16, both together reach it
+	gain := (pokemon.ExperienceForLevel(16)-pokemon.ExperienceForLevel(14))/2 + 1
+
+	// The battle, from the save as it was when the team was built
+	player := newTestPlayer("Ash")
+	player.PlayerID = "ash"
+	bp := newBattlePokemon(charmander)
+	bp.EV++
+	bp.GainedEV++
+	bp.GainedExperience += bp.GainExperience(gain, dex).Gained
+	player.Pokemons = []BattlePokemon{bp}
+
+	// Meanwhile pokecat saves experience for a wild Pokémon knocked out
+	_, err = playerStore.Update("ash", func(save *playerdata.Player) error {
+		save.Caught[0].GainExperience(gain, dex)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordProgress(player, dex)
+
+	save, err := playerStore.Load("ash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := save.Caught[0]
+	if want := pokemon.ExperienceForLevel(14) + 2*gain; got.Experience != want {
+		t.Errorf("experience %d, want %d", got.Experience, want)
+	}
+	if got.EV != 1 {
+		t.Errorf("EV %g, want 1", got.EV)
+	}
+	if got.Level != 16 || got.Name != "Charmeleon" {
+		t.Errorf("got %s Lv.%d, want Charmeleon Lv.16", got.Name, got.Level)
+	}
+	if got.InstanceID != "charmander-1" {
+		t.Errorf("InstanceID %q, want charmander-1", got.InstanceID)
+	}
+}
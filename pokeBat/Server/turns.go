@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"PokemonNetCen/pokeBat/protocol"
+)
+
+// Action kinds a player can submit for a round
+const (
+	ACTION_ATTACK    = "attack"
+	ACTION_SWITCH    = "switch"
+	ACTION_SURRENDER = "surrender"
+)
+
+// Action is what a player decided to do this round.
+type Action struct {
+	Player       *Player
+	Opponent     *Player
+	Kind         string
+	PokemonIndex int // attack: index into Player.Pokemons of the Pokémon that chose the move
+	MoveIndex    int // attack: index into that Pokémon's moveset, -1 for Struggle
+	SwitchIndex  int // switch: index into Player.Pokemons
+
+	// Disconnected is set when the player left instead of answering;
+	// the action is then treated as a surrender.
+	Disconnected bool
+}
+
+// collectActions asks both players for their action at the same time and
+// waits until both have answered.
+func collectActions(battle *Battle) []Action {
+	actions := []Action{
+		{Player: battle.Player1, Opponent: battle.Player2},
+		{Player: battle.Player2, Opponent: battle.Player1},
+	}
+
+	var wg sync.WaitGroup
+	for i := range actions {
+		wg.Add(1)
+		go func(action *Action) {
+			defer wg.Done()
+			if err := chooseAction(action); err != nil {
+				action.Kind = ACTION_SURRENDER
+				action.Disconnected = true
+				return
+			}
+			action.Player.notify("Waiting for your opponent...")
+		}(&actions[i])
+	}
+	wg.Wait()
+
+	return actions
+}
+
+// chooseAction prompts a single player until they submit a valid action.
+// It only fails when the connection is lost.
+func chooseAction(action *Action) error {
+	player := action.Player
+	options := []protocol.Option{
+		{Value: ACTION_ATTACK, Label: "Attack with a move"},
+		{Value: ACTION_SWITCH, Label: "Switch Pokémon"},
+		{Value: ACTION_SURRENDER, Label: "Surrender"},
+	}
+
+	for {
+		kind, err := player.ask(protocol.PromptAction, "Your turn! Choose an action: attack, switch, or surrender", options)
+		if err != nil {
+			return err
+		}
+
+		switch action.Kind = kind; kind {
+		case ACTION_ATTACK:
+			action.PokemonIndex = player.ActivePokemonIndex
+			action.MoveIndex, err = chooseMove(player)
+			return err
+		case ACTION_SWITCH:
+			if !canSwitch(player) {
+				player.reject("You have no other Pokémon that can battle.")
+				continue
+			}
+			action.SwitchIndex, err = chooseSwitch(player)
+			return err
+		case ACTION_SURRENDER:
+			return nil
+		default:
+			player.reject("Invalid action. Please choose attack, switch, or surrender.")
+		}
+	}
+}
+
+// canSwitch reports whether the player has a healthy Pokémon on the bench.
+func canSwitch(player *Player) bool {
+	for i, pokemon := range player.Pokemons {
+		if i != player.ActivePokemonIndex && pokemon.CurrentHP > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseSwitch asks the player which Pokémon to send out next.
+func chooseSwitch(player *Player) (int, error) {
+	// List the bench with each Pokémon's HP
+	var options []protocol.Option
+	for i, pokemon := range player.Pokemons {
+		if i != player.ActivePokemonIndex {
+			options = append(options, protocol.Option{Value: strconv.Itoa(i + 1), Label: fmt.Sprintf("%s (HP: %d/%d)", pokemon.Name, pokemon.CurrentHP, pokemon.BattleStats.HP)})
+		}
+	}
+
+	// Loop until a valid choice is made
+	for {
+		choice, err := player.ask(protocol.PromptSwitch, "Choose a Pokémon to switch to:", options)
+		if err != nil {
+			return 0, err
+		}
+		index, err := strconv.Atoi(choice)
+
+		if err == nil && index >= 1 && index <= len(player.Pokemons) &&
+			index-1 != player.ActivePokemonIndex && player.Pokemons[index-1].CurrentHP > 0 {
+			return index - 1, nil
+		}
+
+		player.reject("Invalid choice. Please choose a valid Pokémon.")
+	}
+}
+
+// orderActions sorts the round's actions into resolution order: surrenders,
+// then switches, then attacks from the fastest active Pokémon to the
+// slowest. Ties are broken with the battle's seeded RNG.
+func orderActions(battle *Battle, actions []Action) {
+	tiebreak := make(map[*Player]int, len(actions))
+	for _, action := range actions {
+		tiebreak[action.Player] = battle.Rng.Int()
+	}
+
+	priority := func(a Action) int {
+		switch a.Kind {
+		case ACTION_SURRENDER:
+			return 0
+		case ACTION_SWITCH:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool {
+		a, b := actions[i], actions[j]
+		if priority(a) != priority(b) {
+			return priority(a) < priority(b)
+		}
+		speedA := a.Player.Pokemons[a.Player.ActivePokemonIndex].BattleStats.Speed
+		speedB := b.Player.Pokemons[b.Player.ActivePokemonIndex].BattleStats.Speed
+		if speedA != speedB {
+			return speedA > speedB
+		}
+		return tiebreak[a.Player] < tiebreak[b.Player]
+	})
+}
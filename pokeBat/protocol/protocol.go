@@ -0,0 +1,266 @@
+// Package protocol defines the newline-delimited JSON messages exchanged
+// between the pokeBat server and its clients.
+//
+// Every message is a single JSON object on its own line. The "type" field
+// says which of the payload fields is set. A session starts with both sides
+// sending a hello carrying the protocol version, after which the client
+// authenticates and the server drives the game with state, prompt, result
+// and end messages. The client only ever answers prompts with an action.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Version is bumped whenever a message changes incompatibly.
+const Version = 1
+
+// MaxLineSize bounds a single message so a misbehaving peer cannot make
+// the reader buffer without limit.
+const MaxLineSize = 64 * 1024
+
+type Type string
+
+const (
+	TypeHello  Type = "hello"
+	TypeAuth   Type = "auth"
+	TypeState  Type = "state"
+	TypePrompt Type = "prompt"
+	TypeAction Type = "action"
+	TypeResult Type = "result"
+	TypeEnd    Type = "end"
+)
+
+// Prompt kinds sent by the server
+const (
+	PromptTeam      = "team"
+	PromptStarter   = "starter"
+	PromptAction    = "action"
+	PromptMove      = "move"
+	PromptSwitch    = "switch"
+	PromptPlayAgain = "play_again"
+)
+
+// Message is the envelope for everything sent on the wire. Exactly one
+// payload matching Type is set.
+type Message struct {
+	Type   Type    `json:"type"`
+	Hello  *Hello  `json:"hello,omitempty"`
+	Auth   *Auth   `json:"auth,omitempty"`
+	State  *State  `json:"state,omitempty"`
+	Prompt *Prompt `json:"prompt,omitempty"`
+	Action *Action `json:"action,omitempty"`
+	Result *Result `json:"result,omitempty"`
+	End    *End    `json:"end,omitempty"`
+}
+
+// Hello is the first message in each direction.
+type Hello struct {
+	Version int    `json:"version"`
+	Name    string `json:"name,omitempty"` // Software name, for logging
+}
+
+// Auth carries the client's credentials.
+type Auth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// PokemonState describes one Pokémon as the receiving player may see it.
+type PokemonState struct {
+	Name     string   `json:"name"`
+	Elements []string `json:"elements"`
+	Level    int      `json:"level"`
+	HP       int      `json:"hp"`
+	MaxHP    int      `json:"max_hp"`
+	Active   bool     `json:"active,omitempty"`
+}
+
+// State is sent to each player at the start of every round.
+type State struct {
+	Round    int            `json:"round"`
+	Opponent string         `json:"opponent"`
+	Team     []PokemonState `json:"team"`
+	Enemy    PokemonState   `json:"enemy"` // The opponent's active Pokémon
+}
+
+// Option is one answer the player may give to a prompt.
+type Option struct {
+	Value string `json:"value"` // Sent back as Action.Choice
+	Label string `json:"label"`
+}
+
+// Prompt asks the player to make a choice. The server waits for an
+// Action answering it before it continues with that player.
+type Prompt struct {
+	Kind    string   `json:"kind"`
+	Text    string   `json:"text"`
+	Options []Option `json:"options,omitempty"`
+}
+
+// Action answers the most recent prompt.
+type Action struct {
+	Prompt string `json:"prompt"`
+	Choice string `json:"choice"`
+}
+
+// Result reports something that happened: the outcome of authentication,
+// a move, a switch, or an error with the last action.
+type Result struct {
+	OK   bool   `json:"ok"`
+	Text string `json:"text"`
+}
+
+// End closes a battle.
+type End struct {
+	Won    bool   `json:"won"`
+	Reason string `json:"reason"`
+}
+
+var (
+	// ErrLineTooLong is returned when a peer sends a line over MaxLineSize.
+	ErrLineTooLong = errors.New("protocol: message exceeds maximum line size")
+
+	// ErrMalformed is returned for a complete line that is not a valid
+	// message. The stream is still usable afterwards.
+	ErrMalformed = errors.New("protocol: malformed message")
+)
+
+// Conn encodes and decodes messages on a stream. Send may be called from
+// several goroutines; Receive must only be called from one at a time.
+type Conn struct {
+	rw     io.ReadWriter
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{rw: rw, reader: bufio.NewReaderSize(rw, 4096)}
+}
+
+// Send writes one message followed by a newline.
+func (c *Conn) Send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.rw.Write(data)
+	return err
+}
+
+// Receive reads the next message, blocking until a whole line has arrived.
+// Blank lines are skipped.
+func (c *Conn) Receive() (Message, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return Message{}, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return Message{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		if err := msg.validate(); err != nil {
+			return Message{}, err
+		}
+		return msg, nil
+	}
+}
+
+func (c *Conn) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := c.reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > MaxLineSize {
+			return nil, ErrLineTooLong
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// validate checks that the payload named by Type is present.
+func (m Message) validate() error {
+	var present bool
+	switch m.Type {
+	case TypeHello:
+		present = m.Hello != nil
+	case TypeAuth:
+		present = m.Auth != nil
+	case TypeState:
+		present = m.State != nil
+	case TypePrompt:
+		present = m.Prompt != nil
+	case TypeAction:
+		present = m.Action != nil
+	case TypeResult:
+		present = m.Result != nil
+	case TypeEnd:
+		present = m.End != nil
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrMalformed, m.Type)
+	}
+	if !present {
+		return fmt.Errorf("%w: %s message without payload", ErrMalformed, m.Type)
+	}
+	return nil
+}
+
+// Constructors for each message type
+
+func NewHello(name string) Message {
+	return Message{Type: TypeHello, Hello: &Hello{Version: Version, Name: name}}
+}
+
+func NewAuth(username, password string) Message {
+	return Message{Type: TypeAuth, Auth: &Auth{Username: username, Password: password}}
+}
+
+func NewState(state State) Message {
+	return Message{Type: TypeState, State: &state}
+}
+
+func NewPrompt(kind, text string, options []Option) Message {
+	return Message{Type: TypePrompt, Prompt: &Prompt{Kind: kind, Text: text, Options: options}}
+}
+
+func NewAction(prompt, choice string) Message {
+	return Message{Type: TypeAction, Action: &Action{Prompt: prompt, Choice: choice}}
+}
+
+func NewResult(ok bool, text string) Message {
+	return Message{Type: TypeResult, Result: &Result{OK: ok, Text: text}}
+}
+
+func NewEnd(won bool, reason string) Message {
+	return Message{Type: TypeEnd, End: &End{Won: won, Reason: reason}}
+}
+
+// CheckHello verifies that a peer's hello speaks our protocol version.
+func CheckHello(msg Message) error {
+	if msg.Type != TypeHello {
+		return fmt.Errorf("protocol: expected hello, got %s", msg.Type)
+	}
+	if msg.Hello.Version != Version {
+		return fmt.Errorf("protocol: version mismatch: peer speaks %d, we speak %d", msg.Hello.Version, Version)
+	}
+	return nil
+}
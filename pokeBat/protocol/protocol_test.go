@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// newTestConn returns a Conn reading from r and writing to a buffer.
+func newTestConn(r io.Reader) *Conn {
+	return NewConn(struct {
+		io.Reader
+		io.Writer
+	}{r, &bytes.Buffer{}})
+}
+
+func TestSendReceive(t *testing.T) {
+	messages := []Message{
+		NewHello("test"),
+		NewAuth("ash", "pikachu"),
+		NewState(State{Round: 1, Opponent: "gary", Team: []PokemonState{{Name: "Pikachu", Elements: []string{"electric"}, Level: 50, HP: 100, MaxHP: 100, Active: true}}}),
+		NewPrompt(PromptMove, "Choose a move", []Option{{Value: "0", Label: "Thunderbolt"}}),
+		NewAction(PromptMove, "0"),
+		NewResult(true, "It's super effective!"),
+		NewEnd(true, "all opposing Pokémon fainted"),
+	}
+
+	var wire bytes.Buffer
+	sender := NewConn(&wire)
+	for _, msg := range messages {
+		if err := sender.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One byte per read, so every message arrives split across reads
+	receiver := newTestConn(iotest.OneByteReader(&wire))
+	for _, want := range messages {
+		got, err := receiver.Receive()
+		if err != nil {
+			t.Fatalf("%s: %v", want.Type, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+	if _, err := receiver.Receive(); err != io.EOF {
+		t.Errorf("after the last message got %v, want EOF", err)
+	}
+}
+
+func TestReceiveMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"not JSON", `{"type": "hello", `},
+		{"unknown type", `{"type": "goodbye"}`},
+		{"hello without payload", `{"type": "hello"}`},
+		{"auth without payload", `{"type": "auth"}`},
+		{"state without payload", `{"type": "state"}`},
+		{"prompt without payload", `{"type": "prompt"}`},
+		{"action without payload", `{"type": "action"}`},
+		{"result without payload", `{"type": "result"}`},
+		{"end without payload", `{"type": "end", "result": {"ok": true}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// A valid message follows the bad line and a blank one
+			conn := newTestConn(strings.NewReader(test.line + "\n\n" + `{"type": "end", "end": {"won": true}}` + "\n"))
+			if _, err := conn.Receive(); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("got %v, want ErrMalformed", err)
+			}
+			msg, err := conn.Receive()
+			if err != nil {
+				t.Fatalf("message after the malformed line: %v", err)
+			}
+			if msg.Type != TypeEnd || !msg.End.Won {
+				t.Errorf("message after the malformed line = %+v, want the end message", msg)
+			}
+		})
+	}
+}
+
+func TestReceiveLineTooLong(t *testing.T) {
+	line := `{"type": "result", "result": {"text": "` + strings.Repeat("a", MaxLineSize) + `"}}` + "\n"
+	conn := newTestConn(strings.NewReader(line))
+	if _, err := conn.Receive(); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("got %v, want ErrLineTooLong", err)
+	}
+}
+
+func TestCheckHello(t *testing.T) {
+	if err := CheckHello(NewHello("test")); err != nil {
+		t.Errorf("own hello: %v", err)
+	}
+	old := NewHello("test")
+	old.Hello.Version = Version - 1
+	if err := CheckHello(old); err == nil {
+		t.Error("hello with another version accepted")
+	}
+	if err := CheckHello(NewAuth("ash", "pikachu")); err == nil {
+		t.Error("auth accepted as hello")
+	}
+}
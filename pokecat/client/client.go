@@ -1,34 +1,103 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
-)
 
-// Define host and port for easy configuration
-const (
-	Host = "http://192.168.1.15" // Change this to your desired host IP
-	Port = "8080"                // Change this to your desired port
+	"PokemonNetCen/config"
 )
 
-// Send HTTP Request Helper
-func sendRequest(url string) string {
-	resp, err := http.Get(url)
+// Base URL of the pokecat server, set with -server, POKECAT_CLIENT_SERVER
+// or a -config file
+var serverURL string
+
+// Path of the API version this client speaks
+const apiPrefix = "/api/v1"
+
+// Session token from /login, sent with every game request
+var sessionToken string
+
+// apiError is the error object the server answers a failed request with.
+type apiError struct {
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// actionResult is the server's answer to a game action.
+type actionResult struct {
+	Messages []string
+	Player   struct {
+		Position [2]int
+		AutoMode bool
+	}
+}
+
+// view is the part of the map around the player.
+type view struct {
+	Origin  [2]int
+	Size    [2]int
+	Pokemon []Sighting
+	Players []struct {
+		Name     string
+		Position [2]int
+	}
+}
+
+// Send API Request Helper: body (if not nil) is sent as JSON and the
+// answer is decoded into out (if not nil).
+func call(method, path string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequest(method, serverURL+apiPrefix+path, payload)
 	if err != nil {
-		fmt.Println("Error:", err)
-		return ""
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return ""
+	if resp.StatusCode >= 400 {
+		var failure struct{ Error *apiError }
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == nil {
+			return fmt.Errorf("server answered %s", resp.Status)
+		}
+		return failure.Error
+	}
+	if out == nil {
+		return nil
 	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
 
-	return string(body)
+// authorize adds the session token to a request, once logged in.
+func authorize(req *http.Request) {
+	if sessionToken != "" {
+		req.Header.Set("Authorization", "Bearer "+sessionToken)
+	}
 }
 
 // Register a New Account
@@ -39,13 +108,12 @@ func register() string {
 	fmt.Print("Enter password for registration: ")
 	fmt.Scanln(&password)
 
-	response := sendRequest(fmt.Sprintf("%s:%s/register?username=%s&password=%s", Host, Port, username, password))
-	fmt.Println(response)
-
-	if strings.Contains(response, "successful") {
-		return username
+	err := call(http.MethodPost, "/register", map[string]string{"Username": username, "Password": password}, nil)
+	if err != nil {
+		fmt.Println(err)
+		return ""
 	}
-	return ""
+	return username
 }
 
 // Login to an Existing Account
@@ -56,44 +124,69 @@ func login() string {
 	fmt.Print("Enter password for login: ")
 	fmt.Scanln(&password)
 
-	response := sendRequest(fmt.Sprintf("%s:%s/login?username=%s&password=%s", Host, Port, username, password))
-	fmt.Println(response)
+	var session struct{ Token string }
+	err := call(http.MethodPost, "/login", map[string]string{"Username": username, "Password": password}, &session)
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+	return session.Token
+}
 
-	if strings.Contains(response, "PlayerID:") {
-		parts := strings.Split(response, "PlayerID: ")
-		if len(parts) > 1 {
-			playerID := strings.TrimSpace(parts[1])
-			return playerID
-		}
+// act sends a game action and prints what happened.
+func act(method, path string, body any) {
+	var result actionResult
+	if err := call(method, path, body, &result); err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, message := range result.Messages {
+		fmt.Println(message)
+	}
+	if path == "/move" {
+		fmt.Println("You are at", tile(result.Player.Position))
 	}
-	return ""
 }
 
 // Display the Grid
-func showGrid(playerID string) {
-	url := fmt.Sprintf("%s:%s/debug/grid?player=%s", Host, Port, playerID)
-	resp, err := http.Get(url)
-	if err != nil {
+func showGrid() {
+	var around view
+	if err := call(http.MethodGet, "/view", nil, &around); err != nil {
 		fmt.Println("Error fetching grid:", err)
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading grid:", err)
-		return
+	grid := make([][]string, around.Size[1])
+	for i := range grid {
+		grid[i] = make([]string, around.Size[0])
+		for j := range grid[i] {
+			grid[i][j] = "."
+		}
+	}
+	for _, wild := range around.Pokemon {
+		grid[wild.Position[1]-around.Origin[1]][wild.Position[0]-around.Origin[0]] = "P"
+	}
+	for _, player := range around.Players {
+		grid[player.Position[1]-around.Origin[1]][player.Position[0]-around.Origin[0]] = "@"
+	}
+	for _, row := range grid {
+		fmt.Println(row)
 	}
-
-	fmt.Println(string(body))
 }
 
 // Main Game Loop
 func main() {
+	flags := flag.NewFlagSet("pokecat client", flag.ExitOnError)
+	flags.StringVar(&serverURL, "server", "http://localhost:8080", "base URL of the pokecat server")
+	if err := config.Load(flags, "POKECAT_CLIENT", os.Args[1:]); err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	serverURL = strings.TrimSuffix(serverURL, "/")
+
 	fmt.Println("Welcome to PokéCat!")
 
 	var choice int
-	var playerID string
 
 	// Authentication Loop
 	for {
@@ -108,11 +201,11 @@ func main() {
 				fmt.Println("Registration successful! Please login now.")
 			}
 		case 2:
-			playerID = login()
-			if playerID != "" {
-				fmt.Println("Login successful! PlayerID:", playerID)
+			sessionToken = login()
+			if sessionToken != "" {
+				fmt.Println("Login successful!")
 				// Explicitly call join after login
-				sendRequest(fmt.Sprintf("%s:%s/join?playerID=%s", Host, Port, playerID))
+				act(http.MethodPost, "/join", nil)
 				goto GameLoop
 			} else {
 				fmt.Println("Login failed, please try again.")
@@ -127,32 +220,47 @@ func main() {
 	}
 
 GameLoop:
-	fmt.Println("Joined the game successfully!")
+	// Print what happens around the player while waiting for commands
+	liveOutput.Store(true)
+	go followEvents()
 
+	// Read whole lines so commands like "auto on" and "throw great" work
+	reader := bufio.NewReader(os.Stdin)
+	moves := map[string]string{"w": "up", "a": "left", "s": "down", "d": "right"}
 	for {
-		var input string
-		fmt.Println("\nEnter command (w/a/s/d for move, auto on/off, grid, save, quit):")
-		fmt.Scanln(&input)
-
-		switch strings.ToLower(input) {
-		case "w":
-			sendRequest(fmt.Sprintf("%s:%s/move?name=%s&direction=up", Host, Port, playerID))
-		case "a":
-			sendRequest(fmt.Sprintf("%s:%s/move?name=%s&direction=left", Host, Port, playerID))
-		case "s":
-			sendRequest(fmt.Sprintf("%s:%s/move?name=%s&direction=down", Host, Port, playerID))
-		case "d":
-			sendRequest(fmt.Sprintf("%s:%s/move?name=%s&direction=right", Host, Port, playerID))
-		case "auto on":
-			sendRequest(fmt.Sprintf("%s:%s/automode?name=%s&enable=true", Host, Port, playerID))
-		case "auto off":
-			sendRequest(fmt.Sprintf("%s:%s/automode?name=%s&enable=false", Host, Port, playerID))
+		fmt.Println("\nEnter command (w/a/s/d for move, throw [poke/great/ultra], battle, flee, auto on/off, live on/off, grid, save, quit):")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println("Exiting the game.")
+			return
+		}
+		input = strings.Join(strings.Fields(strings.ToLower(input)), " ")
+
+		switch input {
+		case "w", "a", "s", "d":
+			act(http.MethodPost, "/move", map[string]string{"Direction": moves[input]})
+		case "throw", "throw poke", "throw great", "throw ultra":
+			ball := strings.TrimSpace(strings.TrimPrefix(input, "throw"))
+			if ball == "" {
+				ball = "poke"
+			}
+			act(http.MethodPost, "/encounter", map[string]string{"Action": ball})
+		case "battle", "flee":
+			act(http.MethodPost, "/encounter", map[string]string{"Action": input})
+		case "auto on", "auto off":
+			enabled := input == "auto on"
+			act(http.MethodPut, "/automode", map[string]bool{"Enabled": enabled})
+			autoMode.Store(enabled)
+		case "live on", "live off":
+			liveOutput.Store(input == "live on")
 		case "grid":
-			showGrid(playerID)
+			showGrid()
 		case "save":
-			sendRequest(fmt.Sprintf("%s:%s/save?name=%s", Host, Port, playerID))
-			fmt.Println("Game state saved.")
+			act(http.MethodPost, "/save", nil)
 		case "quit":
+			if err := call(http.MethodPost, "/logout", nil, nil); err != nil {
+				fmt.Println(err)
+			}
 			fmt.Println("Exiting the game.")
 			return
 		default:
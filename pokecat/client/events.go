@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Event mirrors the server's /events payload.
+type Event struct {
+	Type     string
+	Player   string
+	Position [2]int
+	Pokemon  []Sighting
+	Text     string
+}
+
+// Sighting is a wild Pokémon on the map.
+type Sighting struct {
+	Name     string
+	Level    int
+	Position [2]int
+}
+
+var errSessionEnded = errors.New("session ended")
+
+var (
+	liveOutput atomic.Bool // Print live updates; toggled with "live on/off"
+	autoMode   atomic.Bool // Own moves are only worth printing in auto mode
+)
+
+// followEvents prints the live updates around the player as they arrive,
+// reconnecting after a pause whenever the stream drops.
+func followEvents() {
+	var me string
+	var position [2]int
+	for {
+		err := readEvents(func(event Event) {
+			if event.Type == "view" {
+				me = event.Player
+			}
+			if event.Player == me && (event.Type == "view" || event.Type == "move") {
+				position = event.Position
+			}
+			if text := describe(event, me, position); text != "" && liveOutput.Load() {
+				fmt.Println("[live]", text)
+			}
+		})
+		if errors.Is(err, errSessionEnded) {
+			fmt.Println("[live] Live updates stopped, the session has ended. Please log in again.")
+			return
+		}
+		if err != nil && liveOutput.Load() {
+			fmt.Println("[live] Lost the live updates, reconnecting:", err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// readEvents reads one Server-Sent Events stream until it ends.
+func readEvents(handle func(Event)) error {
+	req, err := http.NewRequest(http.MethodGet, serverURL+apiPrefix+"/events", nil)
+	if err != nil {
+		return err
+	}
+	authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errSessionEnded
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Spawn events can list hundreds of Pokémon
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends the event
+			if data.Len() > 0 {
+				var event Event
+				if err := json.Unmarshal([]byte(data.String()), &event); err == nil {
+					handle(event)
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+		// "event:" repeats the Type in the data, and ":" lines are heartbeats
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream closed")
+}
+
+// describe turns an event into a line for the terminal, or "" if it is
+// not worth printing (your own manual moves are answered by /move).
+func describe(event Event, me string, position [2]int) string {
+	mine := event.Player == me
+	switch event.Type {
+	case "view":
+		return fmt.Sprintf("You are at %s with %d wild Pokémon in view.", tile(event.Position), len(event.Pokemon))
+	case "join":
+		if !mine {
+			return fmt.Sprintf("%s joined at %s.", event.Player, tile(event.Position))
+		}
+	case "move":
+		if !mine {
+			return fmt.Sprintf("%s is at %s.", event.Player, tile(event.Position))
+		}
+		if autoMode.Load() {
+			return fmt.Sprintf("You wandered to %s.", tile(event.Position))
+		}
+	case "spawn":
+		if len(event.Pokemon) == 0 {
+			return ""
+		}
+		closest := event.Pokemon[0]
+		for _, wild := range event.Pokemon[1:] {
+			if distance(position, wild.Position) < distance(position, closest.Position) {
+				closest = wild
+			}
+		}
+		return fmt.Sprintf("%d wild Pokémon appeared nearby, the closest is %s Lv.%d at %s.",
+			len(event.Pokemon), closest.Name, closest.Level, tile(closest.Position))
+	case "despawn":
+		switch len(event.Pokemon) {
+		case 0:
+			return ""
+		case 1:
+			return fmt.Sprintf("The wild %s at %s wandered off.", event.Pokemon[0].Name, tile(event.Pokemon[0].Position))
+		}
+		return fmt.Sprintf("%d wild Pokémon nearby wandered off.", len(event.Pokemon))
+	case "faint":
+		if !mine {
+			return fmt.Sprintf("%s knocked out the wild %s at %s.", event.Player, event.Pokemon[0].Name, tile(event.Position))
+		}
+	case "catch":
+		if !mine {
+			return fmt.Sprintf("%s caught the wild %s at %s.", event.Player, event.Pokemon[0].Name, tile(event.Position))
+		}
+	case "message":
+		return event.Text
+	}
+	return ""
+}
+
+func tile(position [2]int) string {
+	return fmt.Sprintf("(%d, %d)", position[0], position[1])
+}
+
+// distance is the number of steps between two tiles.
+func distance(a, b [2]int) int {
+	return abs(a[0]-b[0]) + abs(a[1]-b[1])
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
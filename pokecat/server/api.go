@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"PokemonNetCen/playerdata"
+)
+
+// apiPrefix is the base path of version 1 of the JSON API. A change that
+// breaks existing clients goes under a new version instead.
+const apiPrefix = "/api/v1"
+
+// apiError is a failed request as the client sees it:
+// {"Error": {"Code": "not_joined", "Message": "..."}} with Status as the
+// HTTP status. Code is stable for clients to check, Message is for people.
+type apiError struct {
+	Status  int `json:"-"`
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+var (
+	errNotFound           = &apiError{http.StatusNotFound, "not_found", "There is no such endpoint. The API is under " + apiPrefix + "."}
+	errMethodNotAllowed   = &apiError{http.StatusMethodNotAllowed, "method_not_allowed", "This endpoint does not accept that method."}
+	errUnauthorized       = &apiError{http.StatusUnauthorized, "unauthorized", "Not logged in or the session expired. Please log in again."}
+	errEmptyCredentials   = &apiError{http.StatusBadRequest, "empty_credentials", "Username and password cannot be empty."}
+	errInvalidCredentials = &apiError{http.StatusUnauthorized, "invalid_credentials", "Invalid username or password."}
+	errUsernameTaken      = &apiError{http.StatusConflict, "username_taken", "Username already exists."}
+	errNotJoined          = &apiError{http.StatusConflict, "not_joined", "Join the game first."}
+	errInEncounter        = &apiError{http.StatusConflict, "in_encounter", "You can't move during an encounter. " + encounterHelp}
+	errNoEncounter        = &apiError{http.StatusConflict, "no_encounter", "You are not in an encounter."}
+	errUnknownDirection   = &apiError{http.StatusBadRequest, "unknown_direction", "Direction must be up, down, left or right."}
+	errUnknownAction      = &apiError{http.StatusBadRequest, "unknown_action", "Unknown action. " + encounterHelp}
+	errInternal           = &apiError{http.StatusInternalServerError, "internal", "Something went wrong on the server."}
+)
+
+// badRequest reports a request body that could not be read.
+func badRequest(format string, args ...any) *apiError {
+	return &apiError{http.StatusBadRequest, "bad_request", fmt.Sprintf(format, args...)}
+}
+
+// credentials is the body of register and login.
+type credentials struct {
+	Username string
+	Password string
+}
+
+// playerState is a joined player as the API reports it.
+type playerState struct {
+	ID        string
+	Name      string
+	Position  [2]int
+	AutoMode  bool
+	Caught    []caughtSummary
+	Encounter *encounterState `json:",omitempty"`
+}
+
+type caughtSummary struct {
+	InstanceID string
+	Name       string
+	Level      int
+}
+
+type encounterState struct {
+	Wild      Sighting
+	CurrentHP int
+	MaxHP     int
+}
+
+// actionResult answers every game action: what happened, in the words the
+// terminal client prints, and the player's state afterwards.
+type actionResult struct {
+	Messages []string
+	Player   playerState
+}
+
+// playerStateOf describes a joined player. The caller must hold the
+// player's lock.
+func playerStateOf(player *playerdata.Player) playerState {
+	state := playerState{
+		ID:       player.ID,
+		Name:     player.Name,
+		Position: player.Position,
+		AutoMode: player.AutoMode,
+		Caught:   make([]caughtSummary, 0, len(player.Caught)),
+	}
+	for _, caught := range player.Caught {
+		state.Caught = append(state.Caught, caughtSummary{InstanceID: caught.InstanceID, Name: caught.Name, Level: caught.Level})
+	}
+	if encounter := encounterOf(player.ID); encounter != nil {
+		state.Encounter = &encounterState{
+			Wild:      sighting(encounter.Wild, encounter.Position),
+			CurrentHP: encounter.CurrentHP,
+			MaxHP:     encounter.Wild.MaxHP(),
+		}
+	}
+	return state
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("[ERROR] Error writing response:", err)
+	}
+}
+
+// writeError answers with the error object. Errors that are not an
+// apiError are logged and reported as internal errors.
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		fmt.Println("[ERROR]", err)
+		apiErr = errInternal
+	}
+	writeJSON(w, apiErr.Status, struct{ Error *apiError }{apiErr})
+}
+
+// readJSON decodes a request body of at most 1 MB, rejecting unknown
+// fields so misspelt ones don't go unnoticed.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("Invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// route registers the handler for one method on an API path and answers
+// every other method with 405 and the Allow header.
+func route(method, path string, handler http.HandlerFunc) {
+	http.HandleFunc(method+" "+apiPrefix+path, handler)
+	http.HandleFunc(apiPrefix+path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", method)
+		writeError(w, errMethodNotAllowed)
+	})
+}
+
+func registerRoutes() {
+	route(http.MethodPost, "/register", handlePlayerRegister)
+	route(http.MethodPost, "/login", handlePlayerLogin)
+	route(http.MethodPost, "/logout", handlePlayerLogout)
+
+	// Everything else acts for the player of the session token
+	route(http.MethodPost, "/join", requireSession(handlePlayerJoin))
+	route(http.MethodGet, "/player", requireSession(handlePlayerState))
+	route(http.MethodPost, "/move", requireSession(handlePlayerMove))
+	route(http.MethodPost, "/encounter", requireSession(handleEncounter))
+	route(http.MethodPut, "/automode", requireSession(handleAutoMode))
+	route(http.MethodPost, "/save", requireSession(handlePlayerSave))
+	route(http.MethodGet, "/view", requireSession(handleView))
+	route(http.MethodGet, "/events", requireSession(handleEvents))
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound)
+	})
+}
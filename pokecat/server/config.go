@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"PokemonNetCen/config"
+	"PokemonNetCen/pokemon"
+)
+
+// Config holds the server settings. Each one is a flag that can also be
+// set with a POKECAT_<FLAG> environment variable or in a -config file.
+type Config struct {
+	Listen           string
+	DataDir          string
+	PlayerDataDir    string
+	UsersFile        string
+	GridSize         int
+	ChunkPopulation  int
+	SpawnInterval    time.Duration
+	DespawnAfter     time.Duration
+	RarityFile       string
+	AutoMoveInterval time.Duration
+	MaxCaught        int
+	SessionTTL       time.Duration
+}
+
+var cfg Config
+
+// rarityFile is the rarity table in the data directory.
+const rarityFile = "rarity.json"
+
+func loadConfig(args []string) (Config, error) {
+	var c Config
+	flags := flag.NewFlagSet("pokecat", flag.ExitOnError)
+	flags.StringVar(&c.Listen, "listen", ":8080", "address to listen on")
+	flags.StringVar(&c.DataDir, "data", "../../data", "data directory holding "+pokemon.PokedexFile)
+	flags.StringVar(&c.PlayerDataDir, "player-data", "../../playerData", "directory of the player saves, shared with pokeBat")
+	flags.StringVar(&c.UsersFile, "users", "users.json", "account store, shared with pokeBat")
+	flags.IntVar(&c.GridSize, "grid-size", 1000, "width and height of the world in tiles")
+	flags.IntVar(&c.ChunkPopulation, "chunk-population", 100, fmt.Sprintf("wild Pokémon kept on each %dx%d chunk of the map", chunkSize, chunkSize))
+	flags.DurationVar(&c.SpawnInterval, "spawn-interval", time.Minute, "time between spawn rounds, which despawn and refill the chunks")
+	flags.DurationVar(&c.DespawnAfter, "despawn-after", 10*time.Minute, "longest a wild Pokémon stays on the map; each stays between half of it and all of it")
+	flags.StringVar(&c.RarityFile, "rarity", "", "JSON table of species spawn weights overriding their catch rates, or empty for catch rates only (default <data>/"+rarityFile+")")
+	flags.DurationVar(&c.AutoMoveInterval, "auto-move-interval", time.Second, "time between steps of players in auto mode")
+	flags.IntVar(&c.MaxCaught, "max-caught", 200, "Pokémon a player can carry")
+	flags.DurationVar(&c.SessionTTL, "session-ttl", 24*time.Hour, "how long a login lasts without requests")
+
+	if err := config.Load(flags, "POKECAT", args); err != nil {
+		return c, err
+	}
+	if !isSet(flags, "rarity") {
+		c.RarityFile = filepath.Join(c.DataDir, rarityFile)
+	}
+	if c.ChunkPopulation < 0 || c.ChunkPopulation > chunkSize*chunkSize {
+		return c, fmt.Errorf("-chunk-population must be between 0 and %d", chunkSize*chunkSize)
+	}
+	if c.SpawnInterval <= 0 || c.DespawnAfter <= 0 {
+		return c, fmt.Errorf("-spawn-interval and -despawn-after must be positive")
+	}
+	if c.GridSize <= 0 || c.MaxCaught <= 0 {
+		return c, fmt.Errorf("-grid-size and -max-caught must be positive")
+	}
+	if c.AutoMoveInterval <= 0 || c.SessionTTL <= 0 {
+		return c, fmt.Errorf("-auto-move-interval and -session-ttl must be positive")
+	}
+	return c, nil
+}
+
+// isSet reports whether a flag was given on the command line, in the
+// environment or in the config file.
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
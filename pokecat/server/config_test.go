@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestLoadConfigRejectsNonPositive(t *testing.T) {
+	if _, err := loadConfig(nil); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"-grid-size", "0"},
+		{"-grid-size", "-5"},
+		{"-max-caught", "0"},
+		{"-auto-move-interval", "0s"},
+		{"-session-ttl", "0s"},
+		{"-session-ttl", "-1h"},
+		{"-spawn-interval", "0s"},
+		{"-despawn-after", "0s"},
+		{"-chunk-population", "-1"},
+	} {
+		if _, err := loadConfig(args); err == nil {
+			t.Errorf("%v accepted", args)
+		}
+	}
+}
+
+func TestLoadConfigRarityFollowsData(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "../../data/rarity.json"},
+		{[]string{"-data", "/elsewhere"}, "/elsewhere/rarity.json"},
+		{[]string{"-data", "/elsewhere", "-rarity", "weights.json"}, "weights.json"},
+		{[]string{"-data", "/elsewhere", "-rarity", ""}, ""},
+	}
+	for _, test := range tests {
+		c, err := loadConfig(test.args)
+		if err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if c.RarityFile != test.want {
+			t.Errorf("%v: -rarity %q, want %q", test.args, c.RarityFile, test.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
+)
+
+const (
+	weakenPower   = 40 // Power of the lead Pokémon's attack when battling a wild one
+	encounterHelp = "Throw a ball (poke, great, ultra), battle or flee."
+)
+
+// Encounter is a wild Pokémon a player stepped on and has not yet caught,
+// knocked out or fled from. The Pokémon stays on the map meanwhile.
+// CurrentHP is guarded by the player's lock, the rest never changes.
+type Encounter struct {
+	Wild      *pokemon.Pokemon
+	Position  [2]int
+	CurrentHP int
+}
+
+// startEncounter begins an encounter with the wild Pokémon on the player's
+// tile. The caller must hold the player's lock.
+func startEncounter(player *playerdata.Player, wild *pokemon.Pokemon) []string {
+	encounter := &Encounter{
+		Wild:      wild,
+		Position:  player.Position,
+		CurrentHP: wild.MaxHP(),
+	}
+	gameState.Mutex.Lock()
+	gameState.Encounters[player.ID] = encounter
+	gameState.Mutex.Unlock()
+	fmt.Printf("[DEBUG] %s encountered %s Lv.%d\n", player.Name, wild.Name, wild.Level)
+	return []string{
+		fmt.Sprintf("A wild %s Lv.%d appeared!", wild.Name, wild.Level),
+		encounterHelp,
+	}
+}
+
+// endEncounter ends the player's encounter.
+func endEncounter(playerID string) {
+	gameState.Mutex.Lock()
+	defer gameState.Mutex.Unlock()
+	delete(gameState.Encounters, playerID)
+}
+
+// resolveEncounter applies the player's action to their current encounter:
+// a ball name, "battle" or "flee". The caller must hold the player's lock.
+func resolveEncounter(player *playerdata.Player, action string) ([]string, error) {
+	encounter := encounterOf(player.ID)
+	if encounter == nil {
+		return nil, errNoEncounter
+	}
+	ball, isBall := pokemon.Balls[action]
+	if !isBall && action != "flee" && action != "battle" {
+		return nil, errUnknownAction
+	}
+
+	// Another player may have caught it, or a respawn replaced it
+	if gameState.World.pokemonAt(encounter.Position) != encounter.Wild {
+		endEncounter(player.ID)
+		return []string{fmt.Sprintf("The wild %s is gone.", encounter.Wild.Name)}, nil
+	}
+
+	switch action {
+	case "flee":
+		endEncounter(player.ID)
+		return []string{"Got away safely!"}, nil
+	case "battle":
+		return weakenWild(player, encounter), nil
+	}
+	return throwBall(player, encounter, ball), nil
+}
+
+// throwBall tries to catch the wild Pokémon. On success it joins the
+// player's catches and leaves the map; otherwise it stays and the
+// encounter goes on.
+func throwBall(player *playerdata.Player, encounter *Encounter, ball pokemon.Ball) []string {
+	wild := encounter.Wild
+	if len(player.Caught) >= cfg.MaxCaught {
+		return []string{"You can't carry any more Pokémon."}
+	}
+
+	chance := pokemon.CatchChance(wild, encounter.CurrentHP, ball)
+	thrown := fmt.Sprintf("You threw the %s!", ball.Name)
+	if rand.Float64() >= chance {
+		return []string{thrown, fmt.Sprintf("Oh no! %s broke free!", wild.Name)}
+	}
+
+	fmt.Printf("[DEBUG] %s caught %s Lv.%d with stats %+v\n", player.Name, wild.Name, wild.Level, wild.ActualStats())
+	caught := *wild
+	caught.InstanceID = playerdata.NewInstanceID()
+	player.Caught = append(player.Caught, caught)
+	savePlayerData(player)
+	gameState.World.removePokemon(encounter.Position, wild)
+	endEncounter(player.ID)
+	publishNear(Event{Type: EventCatch, Player: player.Name, Position: encounter.Position,
+		Pokemon: []Sighting{sighting(wild, encounter.Position)}})
+	return []string{thrown, fmt.Sprintf("Gotcha! %s was caught!", wild.Name)}
+}
+
+// weakenWild has the player's lead Pokémon attack the wild one to make it
+// easier to catch. Knocking it out ends the encounter without a catch but
+// earns the lead experience.
+func weakenWild(player *playerdata.Player, encounter *Encounter) []string {
+	if len(player.Caught) == 0 {
+		return []string{"You have no Pokémon to battle with."}
+	}
+	lead := &player.Caught[0]
+	wild := encounter.Wild
+
+	// Main-series damage formula with a fixed-power attack of the lead's first element
+	attack := float64(lead.ActualStats().Attack)
+	defense := float64(max(wild.ActualStats().Defense, 1))
+	damage := (float64(2*pokemon.ClampLevel(lead.Level)/5+2)*weakenPower*attack/defense)/50 + 2
+	if len(lead.Elements) > 0 {
+		damage *= wild.TypeChart().Multiplier(lead.Elements[0])
+	}
+	encounter.CurrentHP -= max(int(damage), 1)
+
+	messages := []string{fmt.Sprintf("%s attacked the wild %s!", lead.Name, wild.Name)}
+	if encounter.CurrentHP > 0 {
+		return append(messages, fmt.Sprintf("The wild %s has %d/%d HP left.", wild.Name, encounter.CurrentHP, wild.MaxHP()))
+	}
+
+	gameState.World.removePokemon(encounter.Position, wild)
+	endEncounter(player.ID)
+	publishNear(Event{Type: EventFaint, Player: player.Name, Position: encounter.Position,
+		Pokemon: []Sighting{sighting(wild, encounter.Position)}})
+	messages = append(messages, fmt.Sprintf("The wild %s fainted!", wild.Name))
+	return append(messages, awardExperience(player, lead.InstanceID, wild)...)
+}
+
+// awardExperience gives the caught Pokémon experience for defeating a wild
+// one and writes the result to the player's save.
+func awardExperience(player *playerdata.Player, instanceID string, defeated *pokemon.Pokemon) []string {
+	var name string
+	var progress pokemon.Progress
+	saved, err := playerStore.Update(player.ID, func(saved *playerdata.Player) error {
+		i := saved.FindCaught(instanceID)
+		if i < 0 {
+			return nil
+		}
+		name = saved.Caught[i].Name
+		progress = saved.Caught[i].GainExperience(pokemon.ExperienceYield(defeated), pokedex)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("[ERROR] Error saving experience:", err)
+		return nil
+	}
+	player.Caught = saved.Caught
+
+	if name == "" {
+		return nil
+	}
+	messages := []string{fmt.Sprintf("%s gained %d experience points!", name, progress.Gained)}
+	if progress.LeveledUp() {
+		messages = append(messages, fmt.Sprintf("%s grew to level %d!", name, progress.NewLevel))
+	}
+	for _, from := range progress.EvolvedFrom {
+		messages = append(messages, fmt.Sprintf("What? %s is evolving!", from))
+	}
+	if progress.Evolved() {
+		i := saved.FindCaught(instanceID)
+		messages = append(messages, fmt.Sprintf("Congratulations! Your %s evolved into %s!", name, saved.Caught[i].Name))
+	}
+	return messages
+}
+
+// autoEncounter resolves an encounter for a player in auto mode: one
+// Poké Ball, then flee if it broke free.
+func autoEncounter(player *playerdata.Player) []string {
+	messages, _ := resolveEncounter(player, "poke")
+	if encounterOf(player.ID) != nil {
+		fled, _ := resolveEncounter(player, "flee")
+		messages = append(messages, fled...)
+	}
+	fmt.Printf("[DEBUG] Auto encounter for %s: %s\n", player.Name, strings.Join(messages, " "))
+	return messages
+}
+
+func handleEncounter(w http.ResponseWriter, r *http.Request, playerID string) {
+	var body struct{ Action string }
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer player.mu.Unlock()
+
+	messages, err := resolveEncounter(player.Player, strings.ToLower(body.Action))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newResult(player.Player, messages...))
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
+)
+
+// viewSize is the side of the square around a player that /view
+// shows and /events reports on.
+const viewSize = 50
+
+const heartbeatInterval = 15 * time.Second
+
+// Event types streamed by /events
+const (
+	EventView    = "view"    // Sent first: the player's position and the wild Pokémon in view
+	EventJoin    = "join"    // A player joined the game nearby
+	EventMove    = "move"    // A player, possibly you in auto mode, moved
+	EventSpawn   = "spawn"   // Wild Pokémon appeared in view
+	EventDespawn = "despawn" // Wild Pokémon in view despawned as their time was up
+	EventFaint   = "faint"   // A player nearby knocked out a wild Pokémon
+	EventCatch   = "catch"   // A player nearby caught a wild Pokémon
+	EventMessage = "message" // Encounter narration for you, e.g. from auto mode
+)
+
+// Event is one update pushed to a client as a Server-Sent Event, with the
+// event type as the SSE event name and the JSON encoded Event as data.
+type Event struct {
+	Type     string
+	Player   string     `json:",omitempty"` // Name of the player the event is about
+	Position [2]int     // Where it happened; for view and spawn events, your position
+	Pokemon  []Sighting `json:",omitempty"`
+	Text     string     `json:",omitempty"`
+}
+
+// Sighting is a wild Pokémon on the map.
+type Sighting struct {
+	Name     string
+	Level    int
+	Position [2]int
+}
+
+func sighting(wild *pokemon.Pokemon, position [2]int) Sighting {
+	return Sighting{Name: wild.Name, Level: wild.Level, Position: position}
+}
+
+// viewBounds is the /view area centred on center, from the returned
+// origin up to end (exclusive), before clipping to the map.
+func viewBounds(center [2]int) (origin, end [2]int) {
+	return [2]int{center[0] - viewSize/2, center[1] - viewSize/2}, [2]int{center[0] + viewSize/2, center[1] + viewSize/2}
+}
+
+// inView reports whether pos is on the /view area centred on center.
+func inView(center, pos [2]int) bool {
+	origin, end := viewBounds(center)
+	return within(pos, origin, end)
+}
+
+// subscriber is one open /events stream. Events that do not fit in its
+// buffer are dropped rather than holding up the game. Until the stream
+// starts with its snapshot, events are held back in pending instead.
+type subscriber struct {
+	playerID string
+	events   chan Event
+	pending  []Event
+	started  bool
+}
+
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*subscriber]bool // Keyed by PlayerID
+}
+
+var events = eventHub{subscribers: make(map[string]map[*subscriber]bool)}
+
+func (h *eventHub) subscribe(playerID string) *subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := &subscriber{playerID: playerID, events: make(chan Event, 64)}
+	if h.subscribers[playerID] == nil {
+		h.subscribers[playerID] = make(map[*subscriber]bool)
+	}
+	h.subscribers[playerID][s] = true
+	return s
+}
+
+func (h *eventHub) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[s.playerID], s)
+	if len(h.subscribers[s.playerID]) == 0 {
+		delete(h.subscribers, s.playerID)
+	}
+}
+
+// start sends the snapshot a stream begins with, then the events held
+// back while it was taken, so none are missed or sent before it.
+func (h *eventHub) start(s *subscriber, snapshot []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s.started = true
+	for _, event := range append(snapshot, s.pending...) {
+		s.send(event)
+	}
+	s.pending = nil
+}
+
+// sendTo sends the event to every stream of the player.
+func (h *eventHub) sendTo(playerID string, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers[playerID] {
+		s.send(event)
+	}
+}
+
+// send queues the event for the stream. The caller must hold the hub's lock.
+func (s *subscriber) send(event Event) {
+	if !s.started {
+		s.pending = append(s.pending, event)
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		fmt.Println("[DEBUG] Dropped", event.Type, "event for", s.playerID)
+	}
+}
+
+// publishNear sends the event to every player with its position in view,
+// looking only at the players in the chunks around it.
+func publishNear(event Event) {
+	// The players whose view holds the position are those within a view
+	// of it, shifted by one tile since views end before their far edge
+	origin, end := viewBounds(event.Position)
+	origin, end = [2]int{origin[0] + 1, origin[1] + 1}, [2]int{end[0] + 1, end[1] + 1}
+	gameState.World.eachPlayerIn(origin, end, func(viewer *playerdata.Player) {
+		if inView(viewer.Position, event.Position) {
+			events.sendTo(viewer.ID, event)
+		}
+	})
+}
+
+// publishTo sends the event to the player's own streams.
+func publishTo(playerID string, event Event) {
+	events.sendTo(playerID, event)
+}
+
+// publishSightings tells every player on the map which of the wild
+// Pokémon of a spawn round, indexed by chunk, are in their view, as one
+// event each.
+func publishSightings(eventType string, byChunk [][]Sighting) {
+	world := gameState.World
+	world.eachPlayerIn([2]int{0, 0}, [2]int{world.size, world.size}, func(viewer *playerdata.Player) {
+		var visible []Sighting
+		for _, i := range world.chunksIn(viewBounds(viewer.Position)) {
+			for _, wild := range byChunk[i] {
+				if inView(viewer.Position, wild.Position) {
+					visible = append(visible, wild)
+				}
+			}
+		}
+		if len(visible) > 0 {
+			events.sendTo(viewer.ID, Event{Type: eventType, Position: viewer.Position, Pokemon: visible})
+		}
+	})
+}
+
+// handleEvents streams events around a joined player as Server-Sent Events
+// until the client disconnects.
+func handleEvents(w http.ResponseWriter, r *http.Request, playerID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("response writer does not support streaming"))
+		return
+	}
+
+	// Subscribe before taking the snapshot so no event falls between them
+	sub := events.subscribe(playerID)
+	defer events.unsubscribe(sub)
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name := player.Name
+	origin, end := viewBounds(player.Position)
+	snapshot := []Event{{Type: EventView, Player: name, Position: player.Position,
+		Pokemon: gameState.World.pokemonIn(origin, end)}}
+	gameState.World.eachPlayerIn(origin, end, func(other *playerdata.Player) {
+		if other != player.Player {
+			snapshot = append(snapshot, Event{Type: EventMove, Player: other.Name, Position: other.Position})
+		}
+	})
+	player.mu.Unlock()
+	events.start(sub, snapshot)
+
+	fmt.Println("[DEBUG] Streaming events to", name)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			fmt.Println("[DEBUG] Event stream closed for", name)
+			return
+		case <-heartbeat.C:
+			// Comment lines keep proxies from closing an idle stream
+			fmt.Fprint(w, ": ping\n\n")
+		case event := <-sub.events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				fmt.Println("[ERROR] Error encoding event:", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
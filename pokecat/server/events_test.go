@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestEventStreamStartsWithSnapshot(t *testing.T) {
+	hub := eventHub{subscribers: make(map[string]map[*subscriber]bool)}
+	sub := hub.subscribe("ash")
+	defer hub.unsubscribe(sub)
+
+	// Published while the snapshot is taken
+	hub.sendTo("ash", Event{Type: EventMove, Player: "Gary"})
+	hub.start(sub, []Event{{Type: EventView, Player: "Ash"}})
+	hub.sendTo("ash", Event{Type: EventJoin, Player: "Misty"})
+
+	for _, want := range []string{EventView, EventMove, EventJoin} {
+		select {
+		case event := <-sub.events:
+			if event.Type != want {
+				t.Errorf("got a %s event, want %s", event.Type, want)
+			}
+		default:
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
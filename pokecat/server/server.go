@@ -1,558 +1,464 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
-)
-
-// Define host and port for easy configuration
-const (
-	Host = "192.168.1.15" // Change this to your desired host IP or keep it as 0.0.0.0 for all interfaces
-	Port = "8080"         // Change this to your desired port
+	"PokemonNetCen/accounts"
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
 )
 
 // ---- Pokemon stats and structs stored here as well as other structs
-// User represents a registered player
-type User struct {
-	Username string `json:"Username"`
-	Password string `json:"PasswordHash"` // Store hashed password
-	PlayerID string `json:"PlayerID"`
-}
-type Stats struct {
-	HP        int `json:"HP"`
-	Attack    int `json:"Attack"`
-	Defense   int `json:"Defense"`
-	Speed     int `json:"Speed"`
-	SpAttack  int `json:"Sp_Attack"`
-	SpDefense int `json:"Sp_Defense"`
-}
-
-type GenderRatio struct {
-	MaleRatio   float64 `json:"MaleRatio"`
-	FemaleRatio float64 `json:"FemaleRatio"`
-}
 
-type Profile struct {
-	Height      float64     `json:"Height"`
-	Weight      float64     `json:"Weight"`
-	CatchRate   int         `json:"CatchRate"`
-	GenderRatio GenderRatio `json:"GenderRatio"`
-	EggGroup    string      `json:"EggGroup"`
-	HatchSteps  int         `json:"HatchSteps"`
-	Abilities   string      `json:"Abilities"`
+// GameState holds the joined players and their encounters and the map,
+// which has its own locks per chunk. Mutex guards the two maps and is only
+// held to look up, add or remove an entry; everything a player does runs
+// under that player's own lock instead, so players far apart don't hold
+// each other up.
+type GameState struct {
+	Players    map[string]*joinedPlayer
+	Encounters map[string]*Encounter // Keyed by PlayerID
+	Mutex      sync.Mutex
+	World      *World
 }
 
-type DamageCoefficient struct {
-	Element     string  `json:"Element"`
-	Coefficient float64 `json:"Coefficient"`
-}
+var gameState GameState
 
-type Pokemon struct {
-	Name               string              `json:"Name"`
-	Elements           []string            `json:"Elements"`
-	EV                 float64             `json:"EV"`
-	Stats              Stats               `json:"Stats"`
-	Profile            Profile             `json:"Profile"`
-	DamageWhenAttacked []DamageCoefficient `json:"DamegeWhenAttacked"`
-	EvolutionLevel     int                 `json:"EvolutionLevel"`
-	NextEvolution      string              `json:"NextEvolution"`
-	Moves              []string            `json:"Moves"`
-	Experience         int                 `json:"Experience"`
-	Level              int                 `json:"Level"`
+// joinedPlayer is a player in the game. mu is held for each of their
+// actions and guards their fields and the state of their encounter.
+type joinedPlayer struct {
+	mu sync.Mutex
+	*playerdata.Player
 }
 
-type Player struct {
-	ID       string    `json:"ID"`
-	Name     string    `json:"Name"`
-	Position [2]int    `json:"Position"`
-	Caught   []Pokemon `json:"Caught"`
-	AutoMode bool      `json:"AutoMode"`
-}
+// Species data, used to spawn wild Pokémon and to evolve caught ones
+var pokedex *pokemon.Pokedex
 
-type GameState struct {
-	Players  map[string]*Player
-	Pokemons map[[2]int]*Pokemon
-	Mutex    sync.Mutex
-	GridSize int
-}
-
-var gameState GameState
+// Registered accounts, shared with pokeBat
+var accountStore *accounts.Store
 
 //-- Functions that handle the background logic of the game
 
-func loadPokedex() []Pokemon {
-	file, err := os.Open("../../pokedex/pokedex.json")
+func loadPokedex(dataDir string) *pokemon.Pokedex {
+	pokedex, err := pokemon.LoadDir(dataDir)
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
+	return pokedex
+}
 
-	var pokedex []Pokemon
-	if err := json.NewDecoder(file).Decode(&pokedex); err != nil {
-		panic(err)
+func initGameState(gridSize int) {
+	gameState = GameState{
+		Players:    make(map[string]*joinedPlayer),
+		Encounters: make(map[string]*Encounter),
+		World:      newWorld(gridSize),
 	}
-	return pokedex
 }
 
-func spawnPokemons(pokedex []Pokemon, num int) {
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
+// Player saves, shared with pokeBat
+var playerStore *playerdata.Store
 
-	for i := 0; i < num; i++ {
-		x, y := rand.Intn(gameState.GridSize), rand.Intn(gameState.GridSize)
-		pokemon := pokedex[rand.Intn(len(pokedex))]
-		pokemon.Level = rand.Intn(100) + 1
-		pokemon.EV = 0.5 + rand.Float64()*0.5
-		gameState.Pokemons[[2]int{x, y}] = &pokemon
+// savePlayerData writes the player's position, auto mode and new catches to
+// their save. Pokémon already in the save keep the saved version, since
+// pokeBat records battle progress on them, and the in-memory list is
+// refreshed from the result.
+func savePlayerData(player *playerdata.Player) error {
+	// Log player object
+	fmt.Printf("[DEBUG] Player Object: %+v\n", *player)
+	fmt.Println("[DEBUG] Saving player data at:", playerStore.Path(player.ID))
+
+	saved, err := playerStore.Update(player.ID, func(saved *playerdata.Player) error {
+		saved.Name = player.Name
+		saved.Position = player.Position
+		saved.AutoMode = player.AutoMode
+		for _, caught := range player.Caught {
+			if saved.FindCaught(caught.InstanceID) < 0 {
+				saved.Caught = append(saved.Caught, caught)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, playerdata.ErrNotFound) {
+		err = playerStore.Save(player)
+		saved = player
 	}
+	if err != nil {
+		fmt.Println("[ERROR] Error saving player data:", err)
+		return err
+	}
+	player.Caught = saved.Caught
 
-	fmt.Println("[DEBUG] Total Pokémon Spawned:", len(gameState.Pokemons))
+	fmt.Println("[DEBUG] Player data saved successfully:", playerStore.Path(player.ID))
+	return nil
 }
 
-func initGameState(gridSize int) {
-	gameState = GameState{
-		Players:  make(map[string]*Player),
-		Pokemons: make(map[[2]int]*Pokemon),
-		GridSize: gridSize,
+// lockPlayer finds a joined player and locks them for an action. The
+// caller must unlock player.mu when done.
+func lockPlayer(playerID string) (*joinedPlayer, error) {
+	gameState.Mutex.Lock()
+	player, exists := gameState.Players[playerID]
+	gameState.Mutex.Unlock()
+	if !exists {
+		return nil, errNotJoined
+	}
+
+	player.mu.Lock()
+	// They may have left while we waited for their lock
+	gameState.Mutex.Lock()
+	stillJoined := gameState.Players[playerID] == player
+	gameState.Mutex.Unlock()
+	if !stillJoined {
+		player.mu.Unlock()
+		return nil, errNotJoined
 	}
+	return player, nil
 }
 
-const playerDataPath = "../../playerData"
+// joinGame puts a loaded player in the game and on the map. If they
+// already joined, e.g. from a second client, the joined player is
+// returned instead.
+func joinGame(player *playerdata.Player) *joinedPlayer {
+	joined := &joinedPlayer{Player: player}
+	joined.mu.Lock()
+	defer joined.mu.Unlock()
 
-func savePlayerData(player *Player) {
-	// Ensure the directory exists
-	if _, err := os.Stat(playerDataPath); os.IsNotExist(err) {
-		err := os.MkdirAll(playerDataPath, os.ModePerm)
-		if err != nil {
-			fmt.Println("[ERROR] Error creating playerData directory:", err)
-			return
-		}
+	gameState.Mutex.Lock()
+	if existing, exists := gameState.Players[player.ID]; exists {
+		gameState.Mutex.Unlock()
+		return existing
 	}
+	gameState.Players[player.ID] = joined
+	gameState.Mutex.Unlock()
 
-	// Log player object
-	fmt.Printf("[DEBUG] Player Object: %+v\n", *player)
+	gameState.World.addPlayer(player)
+	publishNear(Event{Type: EventJoin, Player: player.Name, Position: player.Position})
+	return joined
+}
 
-	// Proceed to save
-	filename := filepath.Join(playerDataPath, fmt.Sprintf("%s.json", player.ID))
-	fmt.Println("[DEBUG] Saving player data at:", filename)
+// encounterOf returns the player's current encounter, or nil.
+func encounterOf(playerID string) *Encounter {
+	gameState.Mutex.Lock()
+	defer gameState.Mutex.Unlock()
+	return gameState.Encounters[playerID]
+}
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		fmt.Println("[ERROR] Error opening player data file:", err)
-		return
-	}
-	defer file.Close()
+// directions are the ways a player can step.
+var directions = []string{"up", "down", "left", "right"}
 
-	// Marshal player data into JSON
-	jsonData, err := json.MarshalIndent(player, "", "  ")
-	if err != nil {
-		fmt.Println("[ERROR] Error marshalling player data:", err)
-		return
+func movePlayer(playerID string, direction string) (actionResult, error) {
+	if !slices.Contains(directions, direction) {
+		return actionResult{}, errUnknownDirection
 	}
-	fmt.Println("[DEBUG] JSON Data to be written:", string(jsonData))
 
-	// Write JSON data to file
-	_, err = file.Write(jsonData)
+	player, err := lockPlayer(playerID)
 	if err != nil {
-		fmt.Println("[ERROR] Error writing to player data file:", err)
-		return
+		return actionResult{}, err
 	}
+	defer player.mu.Unlock()
 
-	fmt.Println("[DEBUG] Player data saved successfully:", filename)
+	if encounterOf(playerID) != nil {
+		return actionResult{}, errInEncounter
+	}
+	return newResult(player.Player, stepPlayer(player.Player, direction)...), nil
 }
 
-func movePlayer(name string, direction string) {
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
-
-	player, exists := gameState.Players[name]
-	if !exists {
-		return
+// stepPlayer moves the player one tile and starts an encounter if a wild
+// Pokémon is there. The caller must hold the player's lock.
+func stepPlayer(player *playerdata.Player, direction string) []string {
+	if encounter := encounterOf(player.ID); encounter != nil {
+		return []string{fmt.Sprintf("You are in an encounter with a wild %s. %s", encounter.Wild.Name, encounterHelp)}
 	}
 
+	to := player.Position
 	switch direction {
 	case "up":
-		if player.Position[1] > 0 {
-			player.Position[1]--
-		}
+		to[1]--
 	case "down":
-		if player.Position[1] < gameState.GridSize-1 {
-			player.Position[1]++
-		}
+		to[1]++
 	case "left":
-		if player.Position[0] > 0 {
-			player.Position[0]--
-		}
+		to[0]--
 	case "right":
-		if player.Position[0] < gameState.GridSize-1 {
-			player.Position[0]++
-		}
+		to[0]++
 	}
-	// Check to see the player capture any pokemon
-	if pokemon, exists := gameState.Pokemons[player.Position]; exists {
-		if len(player.Caught) < 200 {
-			player.Caught = append(player.Caught, *pokemon)
-			savePlayerData(player)
-			delete(gameState.Pokemons, player.Position)
-		}
+	if to = gameState.World.clamp(to); to != player.Position {
+		gameState.World.movePlayer(player, to)
+		publishNear(Event{Type: EventMove, Player: player.Name, Position: player.Position})
 	}
+
+	// Check to see if the player ran into a wild pokemon
+	if wild := gameState.World.pokemonAt(player.Position); wild != nil {
+		return startEncounter(player, wild)
+	}
+	return nil
 }
 
-func toggleAutoMode(name string, enable bool) {
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
+func toggleAutoMode(playerID string, enable bool) (actionResult, error) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		return actionResult{}, err
+	}
+	defer player.mu.Unlock()
+
+	player.AutoMode = enable
+	if enable {
+		return newResult(player.Player, "Auto mode on."), nil
+	}
+	return newResult(player.Player, "Auto mode off."), nil
+}
 
-	if player, exists := gameState.Players[name]; exists {
-		player.AutoMode = enable
+// newResult reports the outcome of an action. The caller must hold the
+// player's lock.
+func newResult(player *playerdata.Player, messages ...string) actionResult {
+	if messages == nil {
+		messages = []string{}
 	}
+	return actionResult{Messages: messages, Player: playerStateOf(player)}
 }
 
 func autoMovePlayers() {
 	for {
-		time.Sleep(1 * time.Second)
+		time.Sleep(cfg.AutoMoveInterval)
 		gameState.Mutex.Lock()
-		for _, player := range gameState.Players {
-			if player.AutoMode {
-				directions := []string{"up", "down", "left", "right"}
-				movePlayer(player.Name, directions[rand.Intn(len(directions))])
-
-			}
+		playerIDs := make([]string, 0, len(gameState.Players))
+		for playerID := range gameState.Players {
+			playerIDs = append(playerIDs, playerID)
 		}
 		gameState.Mutex.Unlock()
+
+		for _, playerID := range playerIDs {
+			autoMovePlayer(playerID)
+		}
 	}
 }
 
-//---- This part of the program will handle http request between server and client
-
-func handlePlayerJoin(w http.ResponseWriter, r *http.Request) {
-	playerID := r.URL.Query().Get("playerID") // Passed after successful login
-
-	if playerID == "" {
-		w.Write([]byte("PlayerID is required to join"))
+// autoMovePlayer takes a step in a random direction for a player in auto
+// mode and resolves the encounter it starts.
+func autoMovePlayer(playerID string) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
 		return
 	}
-
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
-
-	// Check if player already exists in the game state
-	if _, exists := gameState.Players[playerID]; exists {
-		w.Write([]byte("Player already joined"))
+	defer player.mu.Unlock()
+	if !player.AutoMode {
 		return
 	}
 
-	// Build absolute path for player data using only PlayerID
-	filename := filepath.Join(playerDataPath, fmt.Sprintf("%s.json", playerID))
-	fmt.Println("[DEBUG] Looking for player data at:", filename)
-
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Println("[ERROR] Error loading player data:", err)
-		w.Write([]byte("Error loading player data"))
-		return
+	messages := stepPlayer(player.Player, directions[rand.Intn(len(directions))])
+	if encounterOf(playerID) != nil {
+		messages = append(messages, autoEncounter(player.Player)...)
 	}
-	defer file.Close()
-
-	var player Player
-	err = json.NewDecoder(file).Decode(&player)
-	if err != nil {
-		fmt.Println("[ERROR] Error decoding player data:", err)
-		w.Write([]byte("Error decoding player data"))
-		return
+	for _, message := range messages {
+		publishTo(playerID, Event{Type: EventMessage, Player: player.Name, Position: player.Position, Text: message})
 	}
-
-	// Add player to game state using PlayerID as the key
-	gameState.Players[playerID] = &player
-
-	fmt.Println("[DEBUG] Player successfully joined:", player.Name, "ID:", player.ID)
-	w.Write([]byte("Joined successfully"))
 }
 
-func handlePlayerLogin(w http.ResponseWriter, r *http.Request) {
-	username := r.URL.Query().Get("username")
-	password := r.URL.Query().Get("password")
+//---- This part of the program will handle http request between server and client
 
-	if username == "" || password == "" {
-		w.Write([]byte("Username and password cannot be empty"))
+func handlePlayerRegister(w http.ResponseWriter, r *http.Request) {
+	var body credentials
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	// Load users
-	file, err := os.Open("users.json")
-	if err != nil {
-		w.Write([]byte("Error loading user data"))
+	user, err := accountStore.Register(body.Username, body.Password)
+	switch {
+	case errors.Is(err, accounts.ErrEmptyCredentials):
+		writeError(w, errEmptyCredentials)
+		return
+	case errors.Is(err, accounts.ErrUserExists):
+		writeError(w, errUsernameTaken)
+		return
+	case err != nil:
+		writeError(w, fmt.Errorf("saving user data: %w", err))
 		return
 	}
-	defer file.Close()
-	usersFile := "users.json"
-	absPath, _ := filepath.Abs(usersFile)
-	fmt.Println("[DEBUG] users.json path:", absPath)
-
-	var users []User
-	json.NewDecoder(file).Decode(&users)
-
-	// Find user
-	for _, user := range users {
-		if user.Username == username {
-			// Verify password
-			err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-			if err == nil {
-				w.Write([]byte(fmt.Sprintf("Login successful. PlayerID: %s", user.PlayerID)))
-				return
-			} else {
-				w.Write([]byte("Invalid password"))
-				return
-			}
-		}
-	}
-
-	w.Write([]byte("Username not found"))
-}
+	playerID := user.PlayerID
 
-func handlePlayerRegister(w http.ResponseWriter, r *http.Request) {
-	username := r.URL.Query().Get("username")
-	password := r.URL.Query().Get("password")
+	fmt.Println("[DEBUG] New user registered successfully:", body.Username)
 
-	if username == "" || password == "" {
-		w.Write([]byte("Username and password cannot be empty"))
-		return
+	// Create player file with default data
+	player := playerdata.Player{
+		ID:       playerID,
+		Name:     body.Username,
+		Position: [2]int{0, 0},        // Default starting position
+		Caught:   []pokemon.Pokemon{}, // Empty Pokémon list
+		AutoMode: false,
 	}
 
-	// Load existing users
-	usersFile := "users.json"
-	file, err := os.OpenFile(usersFile, os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		fmt.Println("[ERROR] Error opening users file:", err)
-		w.Write([]byte("Error opening users file"))
+	// Save initial player data to file
+	if err := playerStore.Save(&player); err != nil {
+		writeError(w, fmt.Errorf("creating player data file: %w", err))
 		return
 	}
-	defer file.Close()
 
-	absPath, _ := filepath.Abs(usersFile)
-	fmt.Println("[DEBUG] users.json path:", absPath)
+	fmt.Println("[DEBUG] Player data file created successfully:", playerStore.Path(playerID))
+	writeJSON(w, http.StatusCreated, struct{ PlayerID, Name string }{playerID, player.Name})
+}
 
-	// Read existing users
-	var users []User
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&users)
-	if err != nil && err != io.EOF {
-		fmt.Println("[ERROR] Error decoding users file:", err)
-		w.Write([]byte("Error reading users data"))
+func handlePlayerLogin(w http.ResponseWriter, r *http.Request) {
+	var body credentials
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	// Check if username already exists
-	for _, user := range users {
-		if user.Username == username {
-			w.Write([]byte("Username already exists"))
-			return
-		}
-	}
-
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		fmt.Println("[ERROR] Error hashing password:", err)
-		w.Write([]byte("Error hashing password"))
+	user, err := accountStore.Authenticate(body.Username, body.Password)
+	switch {
+	case errors.Is(err, accounts.ErrEmptyCredentials):
+		writeError(w, errEmptyCredentials)
+		return
+	case errors.Is(err, accounts.ErrWrongPassword), errors.Is(err, accounts.ErrUserNotFound):
+		writeError(w, errInvalidCredentials)
+		return
+	case err != nil:
+		writeError(w, fmt.Errorf("loading user data: %w", err))
 		return
 	}
 
-	// Create a new player ID
-	playerID := uuid.New().String()
-
-	// Add new user
-	newUser := User{
-		Username: username,
-		Password: string(hashedPassword),
-		PlayerID: playerID,
-	}
-	users = append(users, newUser)
-
-	// Clear file and write updated users
-	err = file.Truncate(0) // Clear existing content
+	token, err := sessions.create(user.PlayerID)
 	if err != nil {
-		fmt.Println("[ERROR] Error truncating users file:", err)
-		w.Write([]byte("Error truncating users file"))
+		writeError(w, fmt.Errorf("creating session: %w", err))
 		return
 	}
+	writeJSON(w, http.StatusOK, struct{ Token, PlayerID string }{token, user.PlayerID})
+}
 
-	_, err = file.Seek(0, 0) // Reset pointer to the start
-	if err != nil {
-		fmt.Println("[ERROR] Error seeking users file:", err)
-		w.Write([]byte("Error seeking users file"))
+func handlePlayerJoin(w http.ResponseWriter, r *http.Request, playerID string) {
+	// Joining again, e.g. from a second client, only reports the state
+	if player, err := lockPlayer(playerID); err == nil {
+		defer player.mu.Unlock()
+		writeJSON(w, http.StatusOK, newResult(player.Player, "You are already in the game."))
 		return
 	}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ") // Pretty print JSON
-	err = encoder.Encode(users)
+	fmt.Println("[DEBUG] Looking for player data at:", playerStore.Path(playerID))
+
+	loaded, err := playerStore.Load(playerID)
 	if err != nil {
-		fmt.Println("[ERROR] Error writing to users file:", err)
-		w.Write([]byte("Error saving user data"))
+		writeError(w, fmt.Errorf("loading player data: %w", err))
 		return
 	}
+	joinGame(loaded)
 
-	// Ensure all data is written to disk
-	err = file.Sync()
+	player, err := lockPlayer(playerID)
 	if err != nil {
-		fmt.Println("[ERROR] Error syncing users file:", err)
-		w.Write([]byte("Error syncing users file"))
+		writeError(w, err)
 		return
 	}
+	defer player.mu.Unlock()
 
-	fmt.Println("[DEBUG] New user registered successfully:", username)
-
-	// Create player file with default data
-	playerFile := filepath.Join(playerDataPath, fmt.Sprintf("%s.json", playerID))
-	player := Player{
-		ID:       playerID,
-		Name:     username,
-		Position: [2]int{0, 0}, // Default starting position
-		Caught:   []Pokemon{},  // Empty Pokémon list
-		AutoMode: false,
-	}
+	fmt.Println("[DEBUG] Player successfully joined:", player.Name, "ID:", player.ID)
+	writeJSON(w, http.StatusOK, newResult(player.Player, "Joined the game."))
+}
 
-	// Save initial player data to file
-	playerFileHandle, err := os.OpenFile(playerFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+func handlePlayerState(w http.ResponseWriter, r *http.Request, playerID string) {
+	player, err := lockPlayer(playerID)
 	if err != nil {
-		fmt.Println("[ERROR] Error creating player data file:", err)
-		w.Write([]byte("Error creating player data file"))
+		writeError(w, err)
 		return
 	}
-	defer playerFileHandle.Close()
+	defer player.mu.Unlock()
+	writeJSON(w, http.StatusOK, playerStateOf(player.Player))
+}
 
-	jsonData, err := json.MarshalIndent(player, "", "  ")
-	if err != nil {
-		fmt.Println("[ERROR] Error marshalling initial player data:", err)
-		w.Write([]byte("Error marshalling player data"))
+func handlePlayerMove(w http.ResponseWriter, r *http.Request, playerID string) {
+	var body struct{ Direction string }
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	_, err = playerFileHandle.Write(jsonData)
+	result, err := movePlayer(playerID, body.Direction)
 	if err != nil {
-		fmt.Println("[ERROR] Error writing initial player data:", err)
-		w.Write([]byte("Error writing player data"))
+		writeError(w, err)
 		return
 	}
-
-	fmt.Println("[DEBUG] Player data file created successfully:", playerFile)
-	w.Write([]byte("Registration successful"))
-}
-
-func handlePlayerMove(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	direction := r.URL.Query().Get("direction")
-	movePlayer(name, direction)
-	w.Write([]byte("Moved"))
+	writeJSON(w, http.StatusOK, result)
 }
 
-func handleAutoMode(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	enable := r.URL.Query().Get("enable") == "true"
-	toggleAutoMode(name, enable)
-	w.Write([]byte("Automode toggled"))
-
-}
-func handlePlayerSave(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-
-	if name == "" {
-		w.Write([]byte("Player name is required to save data. Example: /save?name=Ash"))
+func handleAutoMode(w http.ResponseWriter, r *http.Request, playerID string) {
+	var body struct{ Enabled bool }
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
-
-	player, exists := gameState.Players[name]
-	if !exists {
-		w.Write([]byte("Player not found. Ensure you're joined in the game."))
+	result, err := toggleAutoMode(playerID, body.Enabled)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
-
-	savePlayerData(player) // Save the player's data
-	w.Write([]byte("Player data saved successfully!"))
+	writeJSON(w, http.StatusOK, result)
 }
 
-func handleDebugGrid(w http.ResponseWriter, r *http.Request) {
-	playerID := r.URL.Query().Get("player")
-	if playerID == "" {
-		w.Write([]byte("PlayerID is required to display the grid around the player. Example: /debug/grid?player=<PlayerID>"))
+func handlePlayerSave(w http.ResponseWriter, r *http.Request, playerID string) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
+	defer player.mu.Unlock()
 
-	gameState.Mutex.Lock()
-	defer gameState.Mutex.Unlock()
-
-	// Debug: Check if the PlayerID exists
-	player, exists := gameState.Players[playerID]
-	if !exists {
-		fmt.Println("[ERROR] Player not found in gameState.Players. PlayerID:", playerID)
-		w.Write([]byte("Player not found. Please ensure you're joined in the game."))
+	// Save the player's data
+	if err := savePlayerData(player.Player); err != nil {
+		writeError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusOK, newResult(player.Player, "Game saved."))
+}
 
-	fmt.Println("[DEBUG] Found Player in gameState:", playerID, "Position:", player.Position)
-
-	// Grid Size for Visualization
-	viewSize := 50 // Display a 20x20 grid
-
-	// Determine the player's center position
-	centerX := player.Position[0]
-	centerY := player.Position[1]
-
-	// Start and End points for the view
-	startX := max(0, centerX-viewSize/2)
-	startY := max(0, centerY-viewSize/2)
-	endX := min(gameState.GridSize, centerX+viewSize/2)
-	endY := min(gameState.GridSize, centerY+viewSize/2)
-
-	// Create a smaller grid view
-	grid := make([][]string, endY-startY)
-	for i := range grid {
-		grid[i] = make([]string, endX-startX)
-		for j := range grid[i] {
-			grid[i][j] = "."
-		}
-	}
-
-	// Add Pokémon to the visible grid
-	for pos := range gameState.Pokemons {
-		if pos[0] >= startX && pos[0] < endX && pos[1] >= startY && pos[1] < endY {
-			x := pos[0] - startX
-			y := pos[1] - startY
-			grid[y][x] = "P"
-		}
-	}
+// view is the part of the map around a player, the same square the
+// client draws as its grid.
+type view struct {
+	Origin  [2]int // Top left tile
+	Size    [2]int // Width and height, smaller at the edges of the world
+	Pokemon []Sighting
+	Players []playerSighting
+}
 
-	// Add Players to the visible grid
-	for _, otherPlayer := range gameState.Players {
-		if otherPlayer.Position[0] >= startX && otherPlayer.Position[0] < endX &&
-			otherPlayer.Position[1] >= startY && otherPlayer.Position[1] < endY {
-			x := otherPlayer.Position[0] - startX
-			y := otherPlayer.Position[1] - startY
-			grid[y][x] = "@"
-		}
-	}
+type playerSighting struct {
+	Name     string
+	Position [2]int
+}
 
-	// Build and return the grid visualization
-	var result string
-	for _, row := range grid {
-		result += fmt.Sprintf("%s\n", row)
+func handleView(w http.ResponseWriter, r *http.Request, playerID string) {
+	result, err := playerView(playerID)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
+	writeJSON(w, http.StatusOK, result)
+}
 
-	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte(result))
+// playerView looks up the chunks around the player. The player's lock is
+// only held to find where they are.
+func playerView(playerID string) (view, error) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		return view{}, err
+	}
+	center := player.Position
+	player.mu.Unlock()
+
+	origin, end := gameState.World.bounds(viewBounds(center))
+	result := view{
+		Origin:  origin,
+		Size:    [2]int{end[0] - origin[0], end[1] - origin[1]},
+		Pokemon: gameState.World.pokemonIn(origin, end),
+		Players: []playerSighting{},
+	}
+	gameState.World.eachPlayerIn(origin, end, func(otherPlayer *playerdata.Player) {
+		result.Players = append(result.Players, playerSighting{Name: otherPlayer.Name, Position: otherPlayer.Position})
+	})
+	return result, nil
 }
 
 // Helper Functions
@@ -574,8 +480,17 @@ func max(a, b int) int {
 // That we wrote to make a complete server
 
 func main() {
+	var err error
+	cfg, err = loadConfig(os.Args[1:])
+	if err != nil {
+		fmt.Println("[ERROR] Invalid configuration:", err)
+		os.Exit(2)
+	}
+	accountStore = accounts.NewStore(cfg.UsersFile)
+	playerStore = playerdata.NewStore(cfg.PlayerDataDir)
+
 	// Initialize Game State
-	initGameState(1000)
+	initGameState(cfg.GridSize)
 	cwd, err := os.Getwd()
 	if err != nil {
 		fmt.Println("[ERROR] Could not get current working directory:", err)
@@ -584,31 +499,37 @@ func main() {
 	}
 
 	// Load Pokedex
-	pokedex := loadPokedex()
+	pokedex = loadPokedex(cfg.DataDir)
 
-	// Spawn Pokémon every minute
+	// Despawn and refill the map every -spawn-interval
+	rarity, err := loadRarity(cfg.RarityFile)
+	if err != nil {
+		fmt.Println("[ERROR] Error loading rarity table:", err)
+		os.Exit(1)
+	}
+	spawns, err := newSpawner(pokedex, rarity, cfg.ChunkPopulation, cfg.DespawnAfter)
+	if err != nil {
+		fmt.Println("[ERROR]", err)
+		os.Exit(1)
+	}
 	go func() {
 		for {
-			spawnPokemons(pokedex, 100000) // Spawn 50000 Pokémon every minute
-			time.Sleep(1 * time.Minute)
+			spawns.round(time.Now())
+			fmt.Println("[DEBUG] Wild Pokémon on the map:", gameState.World.pokemonCount())
+			time.Sleep(cfg.SpawnInterval)
 		}
 	}()
 
 	// Start Auto Movement for Players
 	go autoMovePlayers()
+	go sweepSessions()
 
 	// HTTP Handlers
-	http.HandleFunc("/register", handlePlayerRegister) // New
-	http.HandleFunc("/login", handlePlayerLogin)       // New
-	http.HandleFunc("/join", handlePlayerJoin)
-	http.HandleFunc("/move", handlePlayerMove)
-	http.HandleFunc("/automode", handleAutoMode)
-	http.HandleFunc("/debug/grid", handleDebugGrid)
-	http.HandleFunc("/save", handlePlayerSave)
+	registerRoutes()
 
 	// Start Server
-	fmt.Println("Server is running on :8080")
-	err = http.ListenAndServe(":8080", nil)
+	fmt.Println("Server is running on", cfg.Listen)
+	err = http.ListenAndServe(cfg.Listen, nil)
 	if err != nil {
 		fmt.Println("Failed to start server:", err)
 	}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader carries the token /login issued, as "Bearer <token>".
+const sessionHeader = "Authorization"
+
+// sessionSweepInterval is how often expired sessions are cleared out and
+// players left without one are taken out of the game.
+const sessionSweepInterval = time.Minute
+
+// session is a logged-in player. Using it moves the expiry forward, so a
+// session only ends after -session-ttl without requests or on /logout.
+// An expired session stays in the store until the next sweep, which also
+// takes its player out of the game.
+type session struct {
+	PlayerID string
+	Expires  time.Time
+}
+
+// sessionStore keeps the sessions in memory; restarting the server logs
+// everybody out.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session // Keyed by token
+}
+
+var sessions = sessionStore{sessions: make(map[string]*session)}
+
+// create starts a session for the player and returns its token.
+func (s *sessionStore) create(playerID string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[token] = &session{PlayerID: playerID, Expires: time.Now().Add(cfg.SessionTTL)}
+	return token, nil
+}
+
+// lookup returns the player of a live session and extends it.
+func (s *sessionStore) lookup(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.sessions[token]
+	if !ok {
+		return "", false
+	}
+	now := time.Now()
+	if now.After(existing.Expires) {
+		return "", false
+	}
+	existing.Expires = now.Add(cfg.SessionTTL)
+	return existing.PlayerID, true
+}
+
+// expire deletes the sessions that expired by now and returns the players
+// they belonged to that have no live session left.
+func (s *sessionStore) expire(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expired := make(map[string]bool)
+	for token, existing := range s.sessions {
+		if now.After(existing.Expires) {
+			delete(s.sessions, token)
+			expired[existing.PlayerID] = true
+		}
+	}
+	for _, existing := range s.sessions {
+		delete(expired, existing.PlayerID)
+	}
+
+	var playerIDs []string
+	for playerID := range expired {
+		playerIDs = append(playerIDs, playerID)
+	}
+	return playerIDs
+}
+
+// revoke ends a session and reports whether the player has other sessions
+// left, e.g. a second client.
+func (s *sessionStore) revoke(token string) (playerID string, othersLeft bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.sessions[token]
+	if !ok {
+		return "", false
+	}
+	delete(s.sessions, token)
+	now := time.Now()
+	for _, other := range s.sessions {
+		if other.PlayerID == existing.PlayerID && now.Before(other.Expires) {
+			return existing.PlayerID, true
+		}
+	}
+	return existing.PlayerID, false
+}
+
+func sessionToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get(sessionHeader), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// requireSession wraps a handler that acts for a player. The player is
+// taken from the session token only, never from the request parameters.
+func requireSession(handle func(w http.ResponseWriter, r *http.Request, playerID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		playerID, ok := sessions.lookup(sessionToken(r))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, errUnauthorized)
+			return
+		}
+		handle(w, r, playerID)
+	}
+}
+
+// handlePlayerLogout revokes the session. Once the player has no session
+// left they are saved and leave the game.
+func handlePlayerLogout(w http.ResponseWriter, r *http.Request) {
+	playerID, othersLeft := sessions.revoke(sessionToken(r))
+	if playerID == "" {
+		writeError(w, errUnauthorized)
+		return
+	}
+
+	if !othersLeft {
+		leaveGame(playerID)
+	}
+	fmt.Println("[DEBUG] Player logged out:", playerID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// leaveGame saves a joined player and takes them and their encounter out
+// of the game.
+func leaveGame(playerID string) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		return
+	}
+	defer player.mu.Unlock()
+
+	savePlayerData(player.Player)
+	gameState.World.removePlayer(player.Player)
+	gameState.Mutex.Lock()
+	delete(gameState.Players, playerID)
+	delete(gameState.Encounters, playerID)
+	gameState.Mutex.Unlock()
+}
+
+// sweepSessions clears out expired sessions every sessionSweepInterval
+// and takes the players left without a session out of the game, as if
+// they had logged out.
+func sweepSessions() {
+	for {
+		time.Sleep(sessionSweepInterval)
+		for _, playerID := range sessions.expire(time.Now()) {
+			leaveGame(playerID)
+			fmt.Println("[DEBUG] Session expired:", playerID)
+		}
+	}
+}
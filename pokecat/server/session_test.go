@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"PokemonNetCen/playerdata"
+)
+
+func TestSessionExpiry(t *testing.T) {
+	cfg.SessionTTL = time.Hour
+	initGameState(100)
+	playerStore = playerdata.NewStore(t.TempDir())
+	store := sessionStore{sessions: make(map[string]*session)}
+
+	// Ash has a second client still logged in, Gary only an expired session
+	players := map[string]*playerdata.Player{
+		"ash":  {ID: "ash", Name: "Ash", Position: [2]int{1, 1}},
+		"gary": {ID: "gary", Name: "Gary", Position: [2]int{2, 2}},
+	}
+	tokens := make(map[string]string)
+	for _, client := range []struct{ name, playerID string }{{"ash", "ash"}, {"ash-phone", "ash"}, {"gary", "gary"}} {
+		token, err := store.create(client.playerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[client.name] = token
+	}
+	for _, player := range players {
+		joinGame(player)
+	}
+
+	later := time.Now().Add(2 * time.Hour)
+	store.sessions[tokens["ash-phone"]].Expires = later.Add(time.Hour)
+
+	// Expired sessions no longer work before the sweep
+	store.sessions[tokens["gary"]].Expires = time.Now().Add(-time.Second)
+	if _, ok := store.lookup(tokens["gary"]); ok {
+		t.Error("expired session still works")
+	}
+
+	left := store.expire(later)
+	if !slices.Equal(left, []string{"gary"}) {
+		t.Fatalf("players left without a session: %v, want [gary]", left)
+	}
+	if _, ok := store.lookup(tokens["ash"]); ok {
+		t.Error("expired session of a player with another session still works")
+	}
+	if _, ok := store.lookup(tokens["ash-phone"]); !ok {
+		t.Error("live session was expired")
+	}
+
+	for _, playerID := range left {
+		leaveGame(playerID)
+	}
+	if _, exists := gameState.Players["gary"]; exists {
+		t.Error("player with an expired session is still in the game")
+	}
+	if _, exists := gameState.Players["ash"]; !exists {
+		t.Error("player with a live session left the game")
+	}
+	gameState.World.eachPlayerIn([2]int{0, 0}, [2]int{100, 100}, func(player *playerdata.Player) {
+		if player.ID == "gary" {
+			t.Error("player with an expired session is still on the map")
+		}
+	})
+	saved, err := playerStore.Load("gary")
+	if err != nil {
+		t.Fatalf("player was not saved: %v", err)
+	}
+	if saved.Position != players["gary"].Position {
+		t.Errorf("saved position %v, want %v", saved.Position, players["gary"].Position)
+	}
+}
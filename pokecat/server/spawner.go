@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"PokemonNetCen/pokemon"
+)
+
+const (
+	levelBand    = 10 // Wild levels span this many levels at any spot
+	spawnRetries = 8  // Random tiles tried for a spawn before giving up on a crowded chunk
+)
+
+// spawner keeps every chunk of the map at its target population. Each round
+// it takes off the wild Pokémon whose time is up and fills the chunks back
+// up on free tiles, with rare species spawning less often and stronger
+// Pokémon further from the origin.
+type spawner struct {
+	species    []pokemon.Pokemon
+	cumulative []float64 // Running total of the species' rarity weights
+	population int       // Target per full chunk
+	lifetime   time.Duration
+}
+
+// newSpawner weighs every species by the rarity table, falling back to its
+// catch rate, so a species with catch rate 3 spawns 85 times less often
+// than one with 255. A weight of 0 keeps a species from spawning.
+func newSpawner(pokedex *pokemon.Pokedex, rarity map[string]float64, population int, lifetime time.Duration) (*spawner, error) {
+	weights := make(map[string]float64, len(rarity))
+	for name, weight := range rarity {
+		species, ok := pokedex.ByName(name)
+		if !ok {
+			return nil, fmt.Errorf("rarity table: unknown species %q", name)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("rarity table: %s has a negative weight", name)
+		}
+		weights[species.Name] = weight
+	}
+
+	s := &spawner{population: population, lifetime: lifetime}
+	total := 0.0
+	for _, species := range pokedex.All() {
+		weight, ok := weights[species.Name]
+		if !ok {
+			weight = species.Profile.CatchRate
+		}
+		if !ok && weight <= 0 {
+			weight = pokemon.DefaultCatchRate
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		s.species = append(s.species, species)
+		s.cumulative = append(s.cumulative, total)
+	}
+	if len(s.species) == 0 {
+		return nil, fmt.Errorf("rarity table leaves no species to spawn")
+	}
+	return s, nil
+}
+
+// loadRarity reads a rarity table, a JSON object of spawn weights keyed by
+// species name: {"Rattata": 255, "Mewtwo": 3}. An empty path is an empty
+// table.
+func loadRarity(path string) (map[string]float64, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rarity map[string]float64
+	if err := json.Unmarshal(data, &rarity); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rarity, nil
+}
+
+// levelRange is the band of levels wild Pokémon spawn at on a tile: 1-10 at
+// the origin, rising with the straight-line distance from it up to 91-100
+// in the far corner.
+func levelRange(pos [2]int, size int) (low, high int) {
+	share := 0.0
+	if far := math.Hypot(float64(size-1), float64(size-1)); far > 0 {
+		share = math.Hypot(float64(pos[0]), float64(pos[1])) / far
+	}
+	low = pokemon.MinLevel + int(share*float64(pokemon.MaxLevel-pokemon.MinLevel+1-levelBand))
+	return low, low + levelBand - 1
+}
+
+// newWild picks a species by rarity at a level for the tile.
+func (s *spawner) newWild(pos [2]int, size int) *pokemon.Pokemon {
+	pick := rand.Float64() * s.cumulative[len(s.cumulative)-1]
+	i := sort.Search(len(s.cumulative), func(i int) bool { return s.cumulative[i] > pick })
+	low, high := levelRange(pos, size)
+	wild := pokemon.NewWild(s.species[min(i, len(s.species)-1)], low+rand.Intn(high-low+1))
+	return &wild
+}
+
+// round despawns and refills every chunk of the map, one chunk lock at a
+// time, then tells the players what they saw come and go.
+func (s *spawner) round(now time.Time) {
+	// Wild Pokémon in an encounter stay until it is over
+	gameState.Mutex.Lock()
+	engaged := make(map[[2]int]bool, len(gameState.Encounters))
+	for _, encounter := range gameState.Encounters {
+		engaged[encounter.Position] = true
+	}
+	gameState.Mutex.Unlock()
+
+	world := gameState.World
+	spawned := make([][]Sighting, len(world.chunks))
+	despawned := make([][]Sighting, len(world.chunks))
+	for i := range world.chunks {
+		spawned[i], despawned[i] = s.tend(world, i, now, engaged)
+	}
+
+	publishSightings(EventSpawn, spawned)
+	publishSightings(EventDespawn, despawned)
+}
+
+// tend despawns the chunk's expired wild Pokémon and spawns new ones until
+// it is back at its target, which shrinks with the chunk on the edges of
+// the map. No Pokémon spawns on a tile that is taken or has a player on it.
+func (s *spawner) tend(w *World, i int, now time.Time, engaged map[[2]int]bool) (spawned, despawned []Sighting) {
+	origin, end := w.chunkBounds(i)
+	c := w.chunks[i]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for pos, expires := range c.expires {
+		if now.After(expires) && !engaged[pos] {
+			despawned = append(despawned, sighting(c.pokemon[pos], pos))
+			delete(c.pokemon, pos)
+			delete(c.expires, pos)
+		}
+	}
+
+	taken := make(map[[2]int]bool, len(c.players))
+	for _, player := range c.players {
+		taken[player.Position] = true
+	}
+	width, height := end[0]-origin[0], end[1]-origin[1]
+	target := s.population * width * height / (chunkSize * chunkSize)
+	for missing := target - len(c.pokemon); missing > 0; missing-- {
+		pos, free := [2]int{}, false
+		for try := 0; try < spawnRetries && !free; try++ {
+			pos = [2]int{origin[0] + rand.Intn(width), origin[1] + rand.Intn(height)}
+			_, occupied := c.pokemon[pos]
+			free = !occupied && !taken[pos]
+		}
+		if !free {
+			break // Crowded; the next rounds fill it up
+		}
+		wild := s.newWild(pos, w.size)
+		c.pokemon[pos] = wild
+		c.expires[pos] = now.Add(s.lifetime/2 + time.Duration(rand.Int63n(int64(s.lifetime/2)+1)))
+		spawned = append(spawned, sighting(wild, pos))
+	}
+	return spawned, despawned
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
+)
+
+// chunkSize is the side of a chunk in tiles. A view is at most 50x50
+// tiles, so it touches no more than 3x3 chunks.
+const chunkSize = 32
+
+// chunk is a square of the map with its own lock, holding the wild Pokémon
+// and the players on its tiles.
+type chunk struct {
+	mu      sync.RWMutex
+	pokemon map[[2]int]*pokemon.Pokemon
+	expires map[[2]int]time.Time          // When each wild Pokémon despawns
+	players map[string]*playerdata.Player // Keyed by PlayerID
+}
+
+// World is the map, split into chunks so that views, moves and spawns
+// only lock and scan the chunks they touch instead of the whole map.
+//
+// A player's Position is only written by the world, holding both the
+// player's lock and the chunk locks, so either is enough to read it.
+type World struct {
+	size   int      // Width and height in tiles
+	side   int      // Width and height in chunks
+	chunks []*chunk // Row by row
+}
+
+func newWorld(size int) *World {
+	side := (size + chunkSize - 1) / chunkSize
+	w := &World{size: size, side: side, chunks: make([]*chunk, side*side)}
+	for i := range w.chunks {
+		w.chunks[i] = &chunk{
+			pokemon: make(map[[2]int]*pokemon.Pokemon),
+			expires: make(map[[2]int]time.Time),
+			players: make(map[string]*playerdata.Player),
+		}
+	}
+	return w
+}
+
+// clamp moves pos onto the map, e.g. a saved position from a bigger world.
+func (w *World) clamp(pos [2]int) [2]int {
+	return [2]int{max(0, min(w.size-1, pos[0])), max(0, min(w.size-1, pos[1]))}
+}
+
+// chunkIndex is the index in w.chunks of the chunk holding pos, which must
+// be on the map.
+func (w *World) chunkIndex(pos [2]int) int {
+	return pos[1]/chunkSize*w.side + pos[0]/chunkSize
+}
+
+func (w *World) chunkAt(pos [2]int) *chunk {
+	return w.chunks[w.chunkIndex(pos)]
+}
+
+// chunkBounds is the area of the chunk at index i, from origin up to end
+// (exclusive). Chunks on the far edges may be cut short by the map.
+func (w *World) chunkBounds(i int) (origin, end [2]int) {
+	origin = [2]int{i % w.side * chunkSize, i / w.side * chunkSize}
+	return w.bounds(origin, [2]int{origin[0] + chunkSize, origin[1] + chunkSize})
+}
+
+// bounds clips the area from origin up to end (exclusive) to the map.
+func (w *World) bounds(origin, end [2]int) ([2]int, [2]int) {
+	origin = [2]int{max(0, origin[0]), max(0, origin[1])}
+	end = [2]int{min(w.size, end[0]), min(w.size, end[1])}
+	return origin, end
+}
+
+// chunksIn returns the indexes of the chunks overlapping the area from
+// origin up to end (exclusive).
+func (w *World) chunksIn(origin, end [2]int) []int {
+	origin, end = w.bounds(origin, end)
+	if origin[0] >= end[0] || origin[1] >= end[1] {
+		return nil
+	}
+	var indexes []int
+	for cy := origin[1] / chunkSize; cy <= (end[1]-1)/chunkSize; cy++ {
+		for cx := origin[0] / chunkSize; cx <= (end[0]-1)/chunkSize; cx++ {
+			indexes = append(indexes, cy*w.side+cx)
+		}
+	}
+	return indexes
+}
+
+func within(pos, origin, end [2]int) bool {
+	return pos[0] >= origin[0] && pos[0] < end[0] && pos[1] >= origin[1] && pos[1] < end[1]
+}
+
+// pokemonAt returns the wild Pokémon on a tile, or nil.
+func (w *World) pokemonAt(pos [2]int) *pokemon.Pokemon {
+	c := w.chunkAt(pos)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pokemon[pos]
+}
+
+// removePokemon takes the wild Pokémon off its tile, unless another one
+// replaced it meanwhile, and reports whether it did.
+func (w *World) removePokemon(pos [2]int, wild *pokemon.Pokemon) bool {
+	c := w.chunkAt(pos)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pokemon[pos] != wild {
+		return false
+	}
+	delete(c.pokemon, pos)
+	delete(c.expires, pos)
+	return true
+}
+
+// pokemonIn lists the wild Pokémon from origin up to end (exclusive).
+func (w *World) pokemonIn(origin, end [2]int) []Sighting {
+	sightings := []Sighting{}
+	for _, i := range w.chunksIn(origin, end) {
+		c := w.chunks[i]
+		c.mu.RLock()
+		for pos, wild := range c.pokemon {
+			if within(pos, origin, end) {
+				sightings = append(sightings, sighting(wild, pos))
+			}
+		}
+		c.mu.RUnlock()
+	}
+	return sightings
+}
+
+// pokemonCount is the number of wild Pokémon on the map.
+func (w *World) pokemonCount() int {
+	count := 0
+	for _, c := range w.chunks {
+		c.mu.RLock()
+		count += len(c.pokemon)
+		c.mu.RUnlock()
+	}
+	return count
+}
+
+// eachPlayerIn calls fn for every player from origin up to end (exclusive)
+// while holding their chunk's read lock, so fn must not lock a chunk.
+func (w *World) eachPlayerIn(origin, end [2]int, fn func(player *playerdata.Player)) {
+	for _, i := range w.chunksIn(origin, end) {
+		c := w.chunks[i]
+		c.mu.RLock()
+		for _, player := range c.players {
+			if within(player.Position, origin, end) {
+				fn(player)
+			}
+		}
+		c.mu.RUnlock()
+	}
+}
+
+// addPlayer puts a joining player on the map. The caller must hold the
+// player's lock.
+func (w *World) addPlayer(player *playerdata.Player) {
+	c := w.chunkAt(w.clamp(player.Position))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	player.Position = w.clamp(player.Position)
+	c.players[player.ID] = player
+}
+
+// removePlayer takes a leaving player off the map. The caller must hold
+// the player's lock.
+func (w *World) removePlayer(player *playerdata.Player) {
+	c := w.chunkAt(player.Position)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.players, player.ID)
+}
+
+// movePlayer moves the player to a tile on the map, locking the chunks it
+// leaves and enters in index order. The caller must hold the player's lock.
+func (w *World) movePlayer(player *playerdata.Player, to [2]int) {
+	from, into := w.chunkIndex(player.Position), w.chunkIndex(to)
+	first, second := w.chunks[min(from, into)], w.chunks[max(from, into)]
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	if second != first {
+		second.mu.Lock()
+		defer second.mu.Unlock()
+	}
+
+	delete(w.chunks[from].players, player.ID)
+	player.Position = to
+	w.chunks[into].players[player.ID] = player
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"PokemonNetCen/playerdata"
+	"PokemonNetCen/pokemon"
+)
+
+// The benchmarks (go test -bench .) run on a world of benchGridSize tiles a
+// side with benchPlayers players, once per wild Pokémon population per chunk.
+const (
+	benchGridSize = 1000
+	benchPlayers  = 1000
+)
+
+var benchPopulations = []int{10, 100, 1000}
+
+var (
+	benchPokedex     *pokemon.Pokedex
+	benchPokedexErr  error
+	loadBenchPokedex sync.Once
+)
+
+// benchWorld is a world filled for a benchmark.
+type benchWorld struct {
+	spawns *spawner // The spawner that filled it
+	clock  time.Time
+	// Players on two free tiles each, the left one first, to walk back and
+	// forth between. The first one is in the middle of the map.
+	players []*playerdata.Player
+}
+
+// runBenchWorld runs bench as a sub-benchmark per population, each on a
+// freshly filled world.
+func runBenchWorld(b *testing.B, bench func(b *testing.B, world *benchWorld)) {
+	loadBenchPokedex.Do(func() {
+		benchPokedex, benchPokedexErr = pokemon.LoadDir("../../data")
+	})
+	if benchPokedexErr != nil {
+		b.Fatal(benchPokedexErr)
+	}
+
+	for _, population := range benchPopulations {
+		b.Run(fmt.Sprintf("chunk-population=%d", population), func(b *testing.B) {
+			spawns, err := newSpawner(benchPokedex, nil, population, 10*time.Minute)
+			if err != nil {
+				b.Fatal(err)
+			}
+			world := &benchWorld{spawns: spawns, clock: time.Now()}
+			world.players = fillWorld(benchGridSize, spawns, world.clock, benchPlayers)
+			b.ReportMetric(float64(gameState.World.pokemonCount()), "pokemon")
+			b.ReportAllocs()
+			b.ResetTimer()
+			bench(b, world)
+		})
+	}
+}
+
+// fillWorld resets the game to a world of the given size filled by the
+// spawner and joins the players, one in the middle and the others spread at
+// random, each on the left of two tiles cleared of wild Pokémon.
+func fillWorld(gridSize int, spawns *spawner, now time.Time, players int) []*playerdata.Player {
+	initGameState(gridSize)
+	spawns.round(now)
+
+	joined := make([]*playerdata.Player, players)
+	for i := range joined {
+		position := [2]int{gridSize / 2, gridSize / 2}
+		if i > 0 {
+			position = [2]int{rand.Intn(gridSize - 1), rand.Intn(gridSize)}
+		}
+		for _, pos := range [][2]int{position, {position[0] + 1, position[1]}} {
+			gameState.World.removePokemon(pos, gameState.World.pokemonAt(pos))
+		}
+		joined[i] = &playerdata.Player{ID: fmt.Sprintf("bench-%d", i), Name: fmt.Sprintf("Bench%d", i), Position: position}
+		joinGame(joined[i])
+	}
+	return joined
+}
+
+// scanView finds the wild Pokémon around center by looking at every one on
+// the map, the way every view did before the map was split into chunks.
+func scanView(center [2]int) []Sighting {
+	var sightings []Sighting
+	for _, c := range gameState.World.chunks {
+		c.mu.RLock()
+		for pos, wild := range c.pokemon {
+			if inView(center, pos) {
+				sightings = append(sightings, sighting(wild, pos))
+			}
+		}
+		c.mu.RUnlock()
+	}
+	return sightings
+}
+
+// walk moves the player between their two free tiles, starting from home,
+// for as long as next says.
+func walk(b *testing.B, player *playerdata.Player, next func() bool) {
+	home, at := player.Position, player.Position
+	for next() {
+		direction := "right"
+		if at != home {
+			direction = "left"
+		}
+		result, err := movePlayer(player.ID, direction)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		at = result.Player.Position
+	}
+}
+
+func BenchmarkView(b *testing.B) {
+	runBenchWorld(b, func(b *testing.B, world *benchWorld) {
+		for i := 0; i < b.N; i++ {
+			playerView(world.players[0].ID)
+		}
+	})
+}
+
+func BenchmarkViewFullScan(b *testing.B) {
+	runBenchWorld(b, func(b *testing.B, world *benchWorld) {
+		for i := 0; i < b.N; i++ {
+			scanView(world.players[0].Position)
+		}
+	})
+}
+
+func BenchmarkMove(b *testing.B) {
+	runBenchWorld(b, func(b *testing.B, world *benchWorld) {
+		i := 0
+		walk(b, world.players[0], func() bool {
+			i++
+			return i <= b.N
+		})
+	})
+}
+
+// BenchmarkMoveParallel has every goroutine walk a different player, to
+// show how far moves on different parts of the map hold each other up.
+func BenchmarkMoveParallel(b *testing.B) {
+	runBenchWorld(b, func(b *testing.B, world *benchWorld) {
+		var next atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			player := world.players[int(next.Add(1)-1)%len(world.players)]
+			walk(b, player, pb.Next)
+		})
+	})
+}
+
+func BenchmarkSpawnRound(b *testing.B) {
+	runBenchWorld(b, func(b *testing.B, world *benchWorld) {
+		// A minute per round, so every Pokémon is replaced within ten
+		for i := 0; i < b.N; i++ {
+			world.clock = world.clock.Add(time.Minute)
+			world.spawns.round(world.clock)
+		}
+	})
+}
+
+// TestConcurrentPlayers walks players while spawn rounds run and views are
+// taken, for go test -race to check the locking, and then checks that every
+// player is on one of their tiles and in the chunk that holds it.
+func TestConcurrentPlayers(t *testing.T) {
+	dex, err := pokemon.LoadDir("../../data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spawns, err := newSpawner(dex, nil, 100, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Now()
+	players := fillWorld(100, spawns, clock, 20)
+	homes := make([][2]int, len(players))
+	for i, player := range players {
+		homes[i] = player.Position
+	}
+
+	var wg sync.WaitGroup
+	for _, player := range players {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			home, at := player.Position, player.Position
+			for i := 0; i < 100; i++ {
+				direction := "right"
+				if at != home {
+					direction = "left"
+				}
+				result, err := movePlayer(player.ID, direction)
+				if err == errInEncounter {
+					// A spawn round put a wild Pokémon on their other tile
+					fleeEncounter(t, player.ID)
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				at = result.Player.Position
+				if _, err := playerView(player.ID); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		clock = clock.Add(time.Minute)
+		spawns.round(clock)
+	}
+	wg.Wait()
+
+	for i, player := range players {
+		position := player.Position
+		if position != homes[i] && position != [2]int{homes[i][0] + 1, homes[i][1]} {
+			t.Errorf("%s is at %v, away from %v", player.Name, position, homes[i])
+		}
+		c := gameState.World.chunkAt(position)
+		if c.players[player.ID] != player {
+			t.Errorf("%s is not in the chunk of %v", player.Name, position)
+		}
+	}
+}
+
+func fleeEncounter(t *testing.T, playerID string) {
+	player, err := lockPlayer(playerID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer player.mu.Unlock()
+	if _, err := resolveEncounter(player.Player, "flee"); err != nil {
+		t.Error(err)
+	}
+}
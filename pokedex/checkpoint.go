@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"PokemonNetCen/pokemon"
+)
+
+// checkpoint holds the species crawled so far, keyed by pokedex number.
+// It is rewritten after every species, so an interrupted crawl resumes
+// where it stopped instead of starting over.
+type checkpoint struct {
+	path    string
+	Entries map[int]pokemon.Pokemon
+}
+
+// loadCheckpoint reads the checkpoint at path, or starts an empty one if
+// there is none yet.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	c := &checkpoint{path: path, Entries: make(map[int]pokemon.Pokemon)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.Entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// add records a crawled species and saves the checkpoint.
+func (c *checkpoint) add(id int, entry pokemon.Pokemon) error {
+	c.Entries[id] = entry
+	js, err := json.Marshal(c.Entries)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, js)
+}
+
+func (c *checkpoint) remove() error {
+	err := os.Remove(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// mergePokedex updates an existing pokedex file with crawled species:
+// entries with the same name are replaced in place and new species are
+// appended in pokedex number order. A missing file starts out empty.
+func mergePokedex(path string, crawled map[int]pokemon.Pokemon) ([]pokemon.Pokemon, error) {
+	entries, err := readPokedex(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		index[entry.Name] = i
+	}
+
+	ids := make([]int, 0, len(crawled))
+	for id := range crawled {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		entry := crawled[id]
+		if i, exists := index[entry.Name]; exists {
+			entries[i] = entry
+			continue
+		}
+		index[entry.Name] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readPokedex reads a pokedex file without validating it, so a partial
+// or inconsistent file can still be merged into. Its schema version and
+// checksum must still match.
+func readPokedex(path string) ([]pokemon.Pokemon, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := pokemon.DecodeFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data.Pokemon, nil
+}
+
+// writeFileAtomic replaces the file through a rename, so a crash never
+// leaves it half written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parseIDs reads a list of pokedex numbers and ranges such as "1-10,25".
+func parseIDs(spec string, last int) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pokedex number %q", part)
+		}
+		end := first
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		if first > end {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		if first < 1 || end > last {
+			return nil, fmt.Errorf("%q is outside 1-%d", part, last)
+		}
+
+		for id := first; id <= end; id++ {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// idsByName looks species up in an existing pokedex file. Entries without
+// a DexNumber are assumed to be in pokedex number order.
+func idsByName(path string, names string) ([]int, error) {
+	entries, err := readPokedex(path)
+	if err != nil {
+		return nil, fmt.Errorf("looking up names needs an existing pokedex: %w", err)
+	}
+
+	var ids []int
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		i := slices.IndexFunc(entries, func(p pokemon.Pokemon) bool { return strings.EqualFold(p.Name, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not in %s", name, path)
+		}
+		id := entries[i].DexNumber
+		if id == 0 {
+			id = i + 1
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"PokemonNetCen/pokemon"
+)
+
+// parseFixtures parses every saved detail page in dir, so a crawl can be
+// re-run without a browser or network. Pages are named after their pokedex
+// number (001.html) and keyed by it.
+func parseFixtures(dir string) (map[int]pokemon.Pokemon, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .html files in %s", dir)
+	}
+
+	entries := make(map[int]pokemon.Pokemon, len(files))
+	for _, file := range files {
+		entry, err := parseFixture(file)
+		if err != nil {
+			return nil, err
+		}
+		entries[entry.DexNumber] = entry
+	}
+	return entries, nil
+}
+
+// parseFixture parses one saved page, taking the pokedex number from its
+// file name.
+func parseFixture(file string) (pokemon.Pokemon, error) {
+	id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".html"))
+	if err != nil || id < 1 {
+		return pokemon.Pokemon{}, fmt.Errorf("%s: name fixtures after their pokedex number", file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return pokemon.Pokemon{}, err
+	}
+	defer f.Close()
+
+	entry, err := parsePokemon(f)
+	if err != nil {
+		return pokemon.Pokemon{}, fmt.Errorf("%s: %w", file, err)
+	}
+	entry.DexNumber = id
+	return entry, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"PokemonNetCen/pokemon"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parsePokemon reads one Pokémon from the HTML of its pokedex.org detail
+// panel. The HTML is either rendered by the browser during a live crawl or
+// loaded from a saved snapshot, so nothing here touches the network.
+func parsePokemon(r io.Reader) (pokemon.Pokemon, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return pokemon.Pokemon{}, err
+	}
+
+	newPokemon := pokemon.Pokemon{}
+	newPokemon.Name = text(doc.Find("div.detail-panel > h1.detail-panel-header"))
+	if newPokemon.Name == "" {
+		return pokemon.Pokemon{}, errors.New("page has no detail panel")
+	}
+
+	newPokemon.Stats = parseStats(doc)
+	newPokemon.Profile = parseProfile(doc)
+	newPokemon.DamageWhenAttacked = parseDamageTable(doc)
+	newPokemon.Genus = text(doc.Find("div.detail-panel-content div.monster-species"))
+	newPokemon.FlavorText = strings.Join(strings.Fields(text(doc.Find("div.detail-panel-content div.monster-description"))), " ")
+	newPokemon.Sprite = parseSprite(doc)
+	newPokemon.Evolutions = parseEvolutions(doc)
+	for _, evolution := range newPokemon.Evolutions {
+		if evolution.From == newPokemon.Name {
+			newPokemon.EvolutionLevel, newPokemon.NextEvolution = evolution.Level, evolution.To
+		}
+	}
+	newPokemon.Moves = parseMoves(doc)
+
+	doc.Find("div.detail-types > span.monster-type").Each(func(_ int, entry *goquery.Selection) {
+		newPokemon.Elements = append(newPokemon.Elements, text(entry))
+	})
+
+	return newPokemon, nil
+}
+
+// text returns the trimmed text of the first matched element.
+func text(selection *goquery.Selection) string {
+	return strings.TrimSpace(selection.First().Text())
+}
+
+func parseStats(doc *goquery.Document) pokemon.Stats {
+	stats := pokemon.Stats{}
+	doc.Find("div.detail-panel-content > div.detail-header > div.detail-infobox > div.detail-stats > div.detail-stats-row").Each(func(_ int, entry *goquery.Selection) {
+		value, _ := strconv.Atoi(text(entry.Find("span.stat-bar > div.stat-bar-fg")))
+
+		switch title := text(entry.Find("span:not([class])")); title {
+		case "HP":
+			stats.HP = value
+		case "Attack":
+			stats.Attack = value
+		case "Defense":
+			stats.Defense = value
+		case "Speed":
+			stats.Speed = value
+		case "Sp Atk":
+			stats.SpAttack = value
+		case "Sp Def":
+			stats.SpDefense = value
+		default:
+			fmt.Println("Unknown title: ", title)
+		}
+	})
+	return stats
+}
+
+func parseProfile(doc *goquery.Document) pokemon.Profile {
+	profile := pokemon.Profile{}
+	doc.Find("div.detail-panel-content > div.detail-below-header > div.monster-minutia").Each(func(_ int, entry *goquery.Selection) {
+		parseMinutia(&profile, text(entry.Find("strong:not([class]):nth-child(1)")), text(entry.Find("span:not([class]):nth-child(2)")))
+		parseMinutia(&profile, text(entry.Find("strong:not([class]):nth-child(3)")), text(entry.Find("span:not([class]):nth-child(4)")))
+	})
+	return profile
+}
+
+// parseDamageTable reads the "when attacked" table, which lists two
+// element/multiplier pairs per row ("fire", "2x").
+func parseDamageTable(doc *goquery.Document) []pokemon.DamageCoefficient {
+	damegeWhenAttacked := []pokemon.DamageCoefficient{}
+	doc.Find("div.when-attacked > div.when-attacked-row").Each(func(_ int, entry *goquery.Selection) {
+		for _, column := range [][2]string{{"1", "2"}, {"3", "4"}} {
+			element := text(entry.Find("span.monster-type:nth-child(" + column[0] + ")"))
+			multiplier := text(entry.Find("span.monster-multiplier:nth-child(" + column[1] + ")"))
+			coefficient, _ := strconv.ParseFloat(strings.TrimSuffix(multiplier, "x"), 64)
+			damegeWhenAttacked = append(damegeWhenAttacked, pokemon.DamageCoefficient{Element: element, Coefficient: coefficient})
+		}
+	})
+	return damegeWhenAttacked
+}
+
+// parseEvolutions reads the whole evolution chain shown on the page, one
+// "<from> evolves into <to> at level <n>." or "<from> evolves into <to>
+// <condition>." row per step.
+func parseEvolutions(doc *goquery.Document) []pokemon.Evolution {
+	var evolutions []pokemon.Evolution
+	doc.Find("div.evolutions > div.evolution-row").Each(func(_ int, entry *goquery.Selection) {
+		if evolution, ok := parseEvolution(text(entry.Find("div.evolution-label > span"))); ok {
+			evolutions = append(evolutions, evolution)
+		}
+	})
+	return evolutions
+}
+
+// parseSprite returns the URL of the detail sprite, from either an <img> or
+// the background image the site uses.
+func parseSprite(doc *goquery.Document) string {
+	sprite := doc.Find("div.detail-sprite").First()
+	if src, ok := sprite.Find("img").Attr("src"); ok {
+		return src
+	}
+	style, _ := sprite.Attr("style")
+	if match := spritePattern.FindStringSubmatch(style); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func parseMoves(doc *goquery.Document) []pokemon.Move {
+	moves := []pokemon.Move{}
+	doc.Find("div.monster-moves > div.moves-row").Each(func(_ int, entry *goquery.Selection) {
+		name := text(entry.Find("div.moves-inner-row > span:nth-child(2)"))
+		if name == "" {
+			return
+		}
+		element := text(entry.Find("div.moves-inner-row > span.monster-type"))
+		learnMethod, learnLevel := parseLearn(text(entry.Find("div.moves-inner-row > span:nth-child(1)")))
+
+		power := parsePower(statValue(entry, 1))
+		acc, _ := firstNumber(statValue(entry, 2))
+		pp, _ := firstNumber(statValue(entry, 3))
+
+		description := text(entry.Find("div.moves-row-detail > div.move-description"))
+
+		moves = append(moves, pokemon.Move{
+			Name:        name,
+			Element:     element,
+			Power:       power,
+			Acc:         int(acc),
+			PP:          int(pp),
+			Description: description,
+			LearnMethod: learnMethod,
+			LearnLevel:  learnLevel,
+		})
+	})
+	return moves
+}
+
+// statValue returns the value of the nth "Label: value" entry in a move's
+// details, or "" if the details were not expanded.
+func statValue(entry *goquery.Selection, n int) string {
+	_, value, _ := strings.Cut(text(entry.Find("div.moves-row-detail > div.moves-row-stats > span:nth-child("+strconv.Itoa(n)+")")), ":")
+	return strings.TrimSpace(value)
+}
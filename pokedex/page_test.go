@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"PokemonNetCen/pokemon"
+)
+
+// After changing the parser on purpose, run go test -update and review the
+// diff of testdata/*.json.
+var update = flag.Bool("update", false, "rewrite the expected testdata/*.json from the parser's output")
+
+// TestParseFixtures parses each saved detail page in testdata and compares
+// the result with the expected entry next to it (001.html, 001.json).
+func TestParseFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no .html fixtures in testdata")
+	}
+
+	fields := []struct {
+		name string
+		get  func(p pokemon.Pokemon) any
+	}{
+		{"identity", func(p pokemon.Pokemon) any { return []any{p.DexNumber, p.Name, p.Genus, p.Elements} }},
+		{"stats", func(p pokemon.Pokemon) any { return p.Stats }},
+		{"profile", func(p pokemon.Pokemon) any { return p.Profile }},
+		{"type matchups", func(p pokemon.Pokemon) any { return p.DamageWhenAttacked }},
+		{"evolutions", func(p pokemon.Pokemon) any { return []any{p.EvolutionLevel, p.NextEvolution, p.Evolutions} }},
+		{"moves", func(p pokemon.Pokemon) any { return p.Moves }},
+		{"entry", func(p pokemon.Pokemon) any { return p }},
+	}
+
+	for _, file := range files {
+		expected := strings.TrimSuffix(file, ".html") + ".json"
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			entry, err := parseFixture(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := json.MarshalIndent(entry, "", "    ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := os.WriteFile(expected, append(parsed, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Compare as decoded from JSON, so empty and missing lists match
+			var got, want pokemon.Pokemon
+			if err := json.Unmarshal(parsed, &got); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("%s: %v", expected, err)
+			}
+
+			for _, field := range fields {
+				if g, w := field.get(got), field.get(want); !reflect.DeepEqual(g, w) {
+					t.Errorf("%s:\n got %+v\nwant %+v", field.name, g, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"PokemonNetCen/pokemon"
+)
+
+var (
+	numberPattern  = regexp.MustCompile(`\d[\d,]*(\.\d+)?`)
+	percentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	levelPattern   = regexp.MustCompile(`(?i)^(?:level|lv\.?|l)?\s*(\d+)$`)
+	spritePattern  = regexp.MustCompile(`url\(["']?([^"')]+)["']?\)`)
+
+	// Words that start an evolution condition after the evolved species' name
+	conditionPattern = regexp.MustCompile(`\s(?:at|with|by|when|after|during|in|while|holding|knowing|upon|on|through|if)\s`)
+)
+
+// firstNumber returns the first number in text, ignoring units, thousands
+// separators and anything in brackets after it ("0.7 m", "5,120",
+// "45 (5.9%)").
+func firstNumber(text string) (float64, bool) {
+	match := numberPattern.FindString(text)
+	if match == "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(strings.ReplaceAll(match, ",", ""), 64)
+	return number, err == nil
+}
+
+// parseMinutia stores one "Title: value" pair of the profile section. The
+// page lays the pairs out in two columns whose order differs between
+// species, so both columns go through here.
+func parseMinutia(profile *pokemon.Profile, title, value string) {
+	title = strings.TrimSuffix(strings.TrimSpace(title), ":")
+	value = strings.TrimSpace(value)
+
+	switch title {
+	case "Height":
+		profile.Height, _ = firstNumber(value)
+	case "Weight":
+		profile.Weight, _ = firstNumber(value)
+	case "Catch Rate":
+		// The species catch rate (0-255); a percentage may follow it
+		profile.CatchRate, _ = firstNumber(value)
+	case "Egg Groups", "Egg Group":
+		profile.EggGroup = parseList(value)
+	case "Abilities", "Ability":
+		profile.Abilities = parseList(value)
+	case "Gender Ratio":
+		profile.GenderRatio = parseGenderRatio(value)
+	case "Hatch Steps":
+		hatchSteps, _ := firstNumber(value)
+		profile.HatchSteps = int(hatchSteps)
+	}
+}
+
+// parseList normalises a comma separated list, dropping stray brackets
+// and quotes left over from the page markup ("]Monster, Grass").
+func parseList(value string) string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.Trim(item, " \t\n[](){}\"'")
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// parseGenderRatio reads "87.5% ♂ 12.5% ♀". Genderless species ("N/A")
+// have both ratios at 0.
+func parseGenderRatio(value string) pokemon.GenderRatio {
+	matches := percentPattern.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return pokemon.GenderRatio{}
+	}
+
+	male, _ := strconv.ParseFloat(matches[0][1], 64)
+	female := 100 - male
+	if len(matches) > 1 {
+		female, _ = strconv.ParseFloat(matches[1][1], 64)
+	}
+	return pokemon.GenderRatio{MaleRatio: male, FemaleRatio: female}
+}
+
+// parsePower returns a move's power as a number, or "—" for moves without
+// one (status moves, shown as "—", "-" or left blank).
+func parsePower(value string) string {
+	power, ok := firstNumber(value)
+	if !ok {
+		return "—"
+	}
+	return strconv.Itoa(int(power))
+}
+
+// parseLearn reads the first column of a move row, which says how the
+// species learns the move: a level ("7", "L7", "Lv. 7"), a machine
+// ("TM06", "HM01"), "Egg" or "Tutor". Anything else is left unknown.
+func parseLearn(value string) (string, int) {
+	value = strings.TrimSpace(value)
+	if match := levelPattern.FindStringSubmatch(value); match != nil {
+		level, _ := strconv.Atoi(match[1])
+		return pokemon.LearnLevelUp, level
+	}
+
+	upper := strings.ToUpper(value)
+	switch {
+	case strings.HasPrefix(upper, "TM"), strings.HasPrefix(upper, "HM"), strings.HasPrefix(upper, "TR"):
+		return pokemon.LearnMachine, 0
+	case strings.HasPrefix(upper, "EGG"):
+		return pokemon.LearnEgg, 0
+	case strings.HasPrefix(upper, "TUTOR"):
+		return pokemon.LearnTutor, 0
+	}
+	return "", 0
+}
+
+// parseEvolution reads one evolution label: "Bulbasaur evolves into Ivysaur
+// at level 16." or "Eevee evolves into Vaporeon with a water stone.".
+// Species names may contain spaces ("Mr. Mime").
+func parseEvolution(label string) (pokemon.Evolution, bool) {
+	label = strings.TrimSuffix(strings.Join(strings.Fields(label), " "), ".")
+	from, rest, ok := strings.Cut(label, " evolves into ")
+	if !ok {
+		return pokemon.Evolution{}, false
+	}
+	evolution := pokemon.Evolution{From: from, To: rest}
+
+	if loc := conditionPattern.FindStringIndex(rest); loc != nil {
+		evolution.To = rest[:loc[0]]
+		evolution.Condition = rest[loc[0]+1:]
+	}
+	if level, found := strings.CutPrefix(evolution.Condition, "at level "); found {
+		if n, err := strconv.Atoi(level); err == nil {
+			evolution.Level = n
+			evolution.Condition = ""
+		}
+	}
+	return evolution, evolution.To != ""
+}
@@ -1,79 +1,175 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"PokemonNetCen/pokemon"
+
 	"github.com/playwright-community/playwright-go"
 )
 
-type Stats struct {
-	HP         int `json:"HP"`
-	Attack     int `json:"Attack"`
-	Defense    int `json:"Defense"`
-	Speed      int `json:"Speed"`
-	Sp_Attack  int `json:"Sp_Attack"`
-	Sp_Defense int `json:"Sp_Defense"`
-}
+const (
+	numberOfPokemons = 649
+	baseURL          = "https://pokedex.org/#/"
+)
 
-type GenderRatio struct {
-	MaleRatio   float32 `json:"MaleRatio"`
-	FemaleRatio float32 `json:"FemaleRatio"`
-}
+func main() {
+	if len(os.Args) > 1 && runQuery(os.Args[1:]) {
+		return
+	}
 
-type Profile struct {
-	Height      float32     `json:"Height"`
-	Weight      float32     `json:"Weight"`
-	CatchRate   float32     `json:"CatchRate"`
-	GenderRatio GenderRatio `json:"GenderRatio"`
-	EggGroup    string      `json:"EggGroup"`
-	HatchSteps  int         `json:"HatchSteps"`
-	Abilities   string      `json:"Abilities"`
-}
+	check := flag.String("check", "", "report problems in an existing pokedex file instead of crawling")
+	fixtures := flag.String("fixtures", "", "parse saved detail pages (<number>.html) in this directory instead of crawling")
+	base := flag.String("base", baseURL, "pokedex site to crawl, e.g. a local static server")
+	dataDir := flag.String("data", "../data", "data directory holding "+pokemon.PokedexFile)
+	out := flag.String("out", "", "pokedex file to merge the crawled species into (default <data>/"+pokemon.PokedexFile+")")
+	ids := flag.String("ids", "", "crawl only these pokedex numbers, e.g. 1-10,25 (default all)")
+	names := flag.String("names", "", "crawl only these species, looked up in -out, e.g. Pikachu,Eevee")
+	workers := flag.Int("workers", 4, "number of browser pages crawling in parallel")
+	retries := flag.Int("retries", 3, "retries per species, with exponential backoff")
+	restart := flag.Bool("restart", false, "ignore the checkpoint of an interrupted crawl")
+	flag.Parse()
+
+	if *out == "" {
+		*out = pokemon.DataPath(*dataDir)
+	}
 
-type DamegeWhenAttacked struct {
-	Element     string  `json:"Element"`
-	Coefficient float32 `json:"Coefficient"`
+	switch {
+	case *check != "":
+		pokedex, err := pokemon.Load(*check)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !report(pokedex.All()) {
+			os.Exit(1)
+		}
+	case *fixtures != "":
+		entries, err := parseFixtures(*fixtures)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mergeAndWrite(*out, entries)
+	default:
+		todo, err := selectIDs(*ids, *names, *out)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		progress, err := loadCheckpoint(*out + ".partial")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *restart {
+			progress.Entries = make(map[int]pokemon.Pokemon)
+		}
+
+		// Skip the species an interrupted crawl already finished
+		todo = slices.DeleteFunc(todo, func(id int) bool {
+			_, done := progress.Entries[id]
+			return done
+		})
+		if done := len(progress.Entries); done > 0 {
+			log.Printf("resuming: %d species already crawled, %d to go", done, len(todo))
+		}
+
+		failed := crawlPokemonsDriver(*base, todo, max(*workers, 1), *retries, progress)
+		mergeAndWrite(*out, progress.Entries)
+		if len(failed) > 0 {
+			log.Fatalf("could not crawl %v; run again to retry them", failed)
+		}
+		if err := progress.remove(); err != nil {
+			log.Println("could not remove checkpoint:", err)
+		}
+	}
 }
 
-type Moves struct {
-	Name        string `json:"Name"`
-	Element     string `json:"Element"`
-	Power       string `json:"Power"`
-	Acc         int    `json:"Acc"`
-	PP          int    `json:"PP"`
-	Description string `json:"Description"`
+// selectIDs returns the pokedex numbers to crawl: the -ids and -names
+// selections combined, or every species if neither is given.
+func selectIDs(ids, names, pokedexFile string) ([]int, error) {
+	if ids == "" && names == "" {
+		return parseIDs(fmt.Sprintf("1-%d", numberOfPokemons), numberOfPokemons)
+	}
+
+	selected, err := parseIDs(ids, numberOfPokemons)
+	if err != nil {
+		return nil, err
+	}
+	if names != "" {
+		byName, err := idsByName(pokedexFile, names)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, byName...)
+	}
+	slices.Sort(selected)
+	return slices.Compact(selected), nil
 }
 
-type Pokemon struct {
-	Name               string               `json:"Name"`
-	Elements           []string             `json:"Elements"`
-	EV                 int                  `json:"EV"`
-	Stats              Stats                `json:"Stats"`
-	Profile            Profile              `json:"Profile"`
-	DamegeWhenAttacked []DamegeWhenAttacked `json:"DamegeWhenAttacked"`
-	EvolutionLevel     int                  `json:"EvolutionLevel"`
-	NextEvolution      string               `json:"NextEvolution"`
-	Moves              []Moves              `json:"Moves"`
+// mergeAndWrite merges the crawled species into the pokedex file, reports
+// problems with the result and writes it.
+func mergeAndWrite(path string, crawled map[int]pokemon.Pokemon) {
+	entries, err := mergePokedex(path, crawled)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// report inconsistent and suspicious entries, but still write what was crawled
+	report(entries)
+	writePokedex(path, entries)
 }
 
-const (
-	numberOfPokemons = 649
-	baseURL          = "https://pokedex.org/#/"
-)
+// report prints the validation errors and suspicious values found in the
+// crawled entries, grouped by field. It returns false if there were any.
+func report(entries []pokemon.Pokemon) bool {
+	ok := true
+	if err := pokemon.Validate(entries); err != nil {
+		log.Println("pokedex validation:", err)
+		ok = false
+	}
 
-var pokemons []Pokemon
+	issues := pokemon.Audit(entries)
+	if len(issues) == 0 {
+		return ok
+	}
 
-func main() {
-	crawlPokemonsDriver(numberOfPokemons)
+	counts := make(map[string]int)
+	var fields []string
+	for _, issue := range issues {
+		if counts[issue.Field] == 0 {
+			fields = append(fields, issue.Field)
+		}
+		counts[issue.Field]++
+		log.Println("pokedex audit:", issue)
+	}
+	for _, field := range fields {
+		log.Printf("pokedex audit: %d of %d species have a problem with %s", counts[field], len(entries), field)
+	}
+	return false
 }
 
-func crawlPokemonsDriver(numsOfPokemons int) {
+// crawlResult is one species crawled by a worker.
+type crawlResult struct {
+	id    int
+	entry pokemon.Pokemon
+	err   error
+}
+
+// crawlPokemonsDriver crawls the given species with a pool of browser
+// pages, saving each one to the checkpoint as it arrives. It returns the
+// pokedex numbers that still failed after all retries.
+func crawlPokemonsDriver(baseURL string, ids []int, workers, retries int, progress *checkpoint) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+
 	pw, err := playwright.Run()
 	if err != nil {
 		log.Fatalf("could not start playwright: %v", err)
@@ -83,33 +179,47 @@ func crawlPokemonsDriver(numsOfPokemons int) {
 		log.Fatalf("could not launch browser: %v", err)
 	}
 
-	page, err := browser.NewPage()
-	if err != nil {
-		log.Fatalf("could not create page: %v", err)
-	}
-
-	page.Goto(baseURL)
-
-	for i := range numsOfPokemons {
-		// simulate clicking the button to open the pokemon details
-		locator := fmt.Sprintf("button.sprite-%d", i+1)
-		button := page.Locator(locator).First()
-		time.Sleep(500 * time.Millisecond)
-		button.Click()
-
-		fmt.Print("Pokemon ", i+1, " ")
-		crawlPokemons(page)
+	jobs := make(chan int)
+	results := make(chan crawlResult)
+	var wg sync.WaitGroup
+	for range min(workers, len(ids)) {
+		page, err := browser.NewPage()
+		if err != nil {
+			log.Fatalf("could not create page: %v", err)
+		}
 
-		page.Goto(baseURL)
-		page.Reload()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				entry, err := crawlWithRetry(page, baseURL, id, retries)
+				results <- crawlResult{id: id, entry: entry, err: err}
+			}
+		}()
 	}
 
-	// parse the pokemons variable to json file
-	js, err := json.MarshalIndent(pokemons, "", "    ")
-	if err != nil {
-		log.Fatal(err)
+	go func() {
+		for _, id := range ids {
+			jobs <- id
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var failed []int
+	for result := range results {
+		if result.err != nil {
+			log.Printf("Pokemon %d failed: %v", result.id, result.err)
+			failed = append(failed, result.id)
+			continue
+		}
+
+		fmt.Println("Pokemon", result.id, result.entry.Name, ": ", result.entry.Profile)
+		if err := progress.add(result.id, result.entry); err != nil {
+			log.Fatalf("could not save checkpoint: %v", err)
+		}
 	}
-	os.WriteFile("pokedex.json", js, 0644)
 
 	if err = browser.Close(); err != nil {
 		log.Fatalf("could not close browser: %v", err)
@@ -117,162 +227,77 @@ func crawlPokemonsDriver(numsOfPokemons int) {
 	if err = pw.Stop(); err != nil {
 		log.Fatalf("could not stop Playwright: %v", err)
 	}
+
+	slices.Sort(failed)
+	return failed
 }
 
-func crawlPokemons(page playwright.Page) {
-	pokemon := Pokemon{}
-
-	stats := Stats{}
-	entries, _ := page.Locator("div.detail-panel-content > div.detail-header > div.detail-infobox > div.detail-stats > div.detail-stats-row").All()
-	for _, entry := range entries {
-		title, _ := entry.Locator("span:not([class])").TextContent()
-		switch title {
-		case "HP":
-			hp, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.HP, _ = strconv.Atoi(hp)
-		case "Attack":
-			attack, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.Attack, _ = strconv.Atoi(attack)
-		case "Defense":
-			defense, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.Defense, _ = strconv.Atoi(defense)
-		case "Speed":
-			speed, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.Speed, _ = strconv.Atoi(speed)
-		case "Sp Atk":
-			sp_Attack, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.Sp_Attack, _ = strconv.Atoi(sp_Attack)
-		case "Sp Def":
-			sp_Defense, _ := entry.Locator("span.stat-bar > div.stat-bar-fg").TextContent()
-			stats.Sp_Defense, _ = strconv.Atoi(sp_Defense)
-		default:
-			fmt.Println("Unknown title: ", title)
-		}
-	}
-	pokemon.Stats = stats
-
-	name, _ := page.Locator("div.detail-panel > h1.detail-panel-header").TextContent()
-	pokemon.Name = name
-
-	genderRatio := GenderRatio{}
-	profile := Profile{}
-	entries, _ = page.Locator("div.detail-panel-content > div.detail-below-header > div.monster-minutia").All()
-	for _, entry := range entries {
-		title1, _ := entry.Locator("strong:not([class]):nth-child(1)").TextContent()
-		stat1, _ := entry.Locator("span:not([class]):nth-child(2)").TextContent()
-		switch title1 {
-		case "Height:":
-			heights := strings.Split(stat1, " ")
-			height, _ := strconv.ParseFloat(heights[0], 32)
-			profile.Height = float32(height)
-		case "Catch Rate:":
-			catchRates := strings.Split(stat1, "%")
-			catchRate, _ := strconv.ParseFloat(catchRates[0], 32)
-			profile.CatchRate = float32(catchRate)
-		case "Egg Groups:":
-			profile.EggGroup = stat1
-		case "Abilities:":
-			profile.Abilities = stat1
+// crawlWithRetry crawls one species, waiting 1s, 2s, 4s... between attempts.
+func crawlWithRetry(page playwright.Page, baseURL string, id, retries int) (pokemon.Pokemon, error) {
+	for attempt := 0; ; attempt++ {
+		entry, err := crawlPokemon(page, baseURL, id)
+		if err == nil || attempt >= retries {
+			return entry, err
 		}
 
-		title2, _ := entry.Locator("strong:not([class]):nth-child(3)").TextContent()
-		stat2, _ := entry.Locator("span:not([class]):nth-child(4)").TextContent()
-		switch title2 {
-		case "Weight:":
-			weights := strings.Split(stat2, " ")
-			weight, _ := strconv.ParseFloat(weights[0], 32)
-			profile.Weight = float32(weight)
-		case "Gender Ratio:":
-			if stat2 == "N/A" {
-				genderRatio.MaleRatio = 0
-				genderRatio.FemaleRatio = 0
-			} else {
-				ratios := strings.Split(stat2, " ")
-
-				maleRatios := strings.Split(ratios[0], "%")
-				maleRatio, _ := strconv.ParseFloat(maleRatios[0], 32)
-				genderRatio.MaleRatio = float32(maleRatio)
-
-				femaleRatios := strings.Split(ratios[2], "%")
-				femaleRatio, _ := strconv.ParseFloat(femaleRatios[0], 32)
-				genderRatio.FemaleRatio = float32(femaleRatio)
-			}
+		delay := time.Second << attempt
+		log.Printf("Pokemon %d: %v, retrying in %s", id, err, delay)
+		time.Sleep(delay)
+	}
+}
 
-			profile.GenderRatio = genderRatio
-		case "Hatch Steps:":
-			profile.HatchSteps, _ = strconv.Atoi(stat2)
-		}
+// crawlPokemon opens the detail panel of one species and parses it.
+func crawlPokemon(page playwright.Page, baseURL string, id int) (pokemon.Pokemon, error) {
+	if _, err := page.Goto(baseURL); err != nil {
+		return pokemon.Pokemon{}, err
 	}
-	pokemon.Profile = profile
-
-	damegeWhenAttacked := []DamegeWhenAttacked{}
-	entries, _ = page.Locator("div.when-attacked > div.when-attacked-row").All()
-	for _, entry := range entries {
-		element1, _ := entry.Locator("span.monster-type:nth-child(1)").TextContent()
-		coefficient1, _ := entry.Locator("span.monster-multiplier:nth-child(2)").TextContent()
-		coefficients1 := strings.Split(coefficient1, "x")
-		coef1, _ := strconv.ParseFloat(coefficients1[0], 32)
-
-		element2, _ := entry.Locator("span.monster-type:nth-child(3)").TextContent()
-		coefficient2, _ := entry.Locator("span.monster-multiplier:nth-child(4)").TextContent()
-		coefficients2 := strings.Split(coefficient2, "x")
-		coef2, _ := strconv.ParseFloat(coefficients2[0], 32)
-
-		damegeWhenAttacked = append(damegeWhenAttacked, DamegeWhenAttacked{Element: element1, Coefficient: float32(coef1)})
-		damegeWhenAttacked = append(damegeWhenAttacked, DamegeWhenAttacked{Element: element2, Coefficient: float32(coef2)})
+	if _, err := page.Reload(); err != nil {
+		return pokemon.Pokemon{}, err
 	}
-	pokemon.DamegeWhenAttacked = damegeWhenAttacked
-
-	entries, _ = page.Locator("div.evolutions > div.evolution-row").All()
-	for _, entry := range entries {
-		evolutionLabel, _ := entry.Locator("div.evolution-label > span").TextContent()
-		evolutionLabels := strings.Split(evolutionLabel, " ")
 
-		if evolutionLabels[0] == name {
-			evolutionLevels := strings.Split(evolutionLabels[len(evolutionLabels)-1], ".")
-			evolutionLevel, _ := strconv.Atoi(evolutionLevels[0])
-			pokemon.EvolutionLevel = evolutionLevel
-
-			nextEvolution := evolutionLabels[3]
-			pokemon.NextEvolution = nextEvolution
-		}
+	// simulate clicking the button to open the pokemon details
+	locator := fmt.Sprintf("button.sprite-%d", id)
+	if err := page.Locator(locator).First().Click(); err != nil {
+		return pokemon.Pokemon{}, err
+	}
+	if err := page.Locator("div.detail-panel > h1.detail-panel-header").WaitFor(); err != nil {
+		return pokemon.Pokemon{}, err
 	}
 
-	moves := []Moves{}
-	entries, _ = page.Locator("div.monster-moves > div.moves-row").All()
-	for _, entry := range entries {
-		// simulate clicking the expand button in the move rows
-		expandButton := page.Locator("div.moves-inner-row > button.dropdown-button").First()
-		expandButton.Click()
-
-		name, _ := entry.Locator("div.moves-inner-row > span:nth-child(2)").TextContent()
-		element, _ := entry.Locator("div.moves-inner-row > span.monster-type").TextContent()
-
-		powers, _ := entry.Locator("div.moves-row-detail > div.moves-row-stats > span:nth-child(1)").TextContent()
-		power := strings.Split(powers, ":")
-
-		acc, _ := entry.Locator("div.moves-row-detail > div.moves-row-stats > span:nth-child(2)").TextContent()
-		accs := strings.Split(acc, ":")
-		accValue := strings.Split(accs[1], "%")
-		accInt, _ := strconv.Atoi(accValue[0])
-
-		pps, _ := entry.Locator("div.moves-row-detail > div.moves-row-stats > span:nth-child(3)").TextContent()
-		ppVal := strings.Split(pps, ":")
-		pp, _ := strconv.Atoi(ppVal[1])
-
-		description, _ := entry.Locator("div.moves-row-detail > div.move-description").TextContent()
+	entry, err := crawlPokemons(page)
+	entry.DexNumber = id
+	return entry, err
+}
 
-		moves = append(moves, Moves{Name: name, Element: element, Power: power[1], Acc: accInt, PP: pp, Description: description})
+// writePokedex writes the entries as a versioned pokedex file.
+func writePokedex(path string, entries []pokemon.Pokemon) {
+	var js bytes.Buffer
+	if err := pokemon.Encode(&js, entries); err != nil {
+		log.Fatal(err)
 	}
-	pokemon.Moves = moves
-
-	entries, _ = page.Locator("div.detail-types > span.monster-type").All()
-	for _, entry := range entries {
-		element, _ := entry.TextContent()
-		pokemon.Elements = append(pokemon.Elements, element)
+	if err := writeFileAtomic(path, js.Bytes()); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	fmt.Println(name, ": ", profile)
+// crawlPokemons reads the detail panel the browser has open. Navigation
+// stays here; the parsing is shared with the offline fixture mode.
+func crawlPokemons(page playwright.Page) (pokemon.Pokemon, error) {
+	// simulate clicking the expand button of every move row, so that the
+	// power, accuracy, PP and description are in the page
+	expandButtons, err := page.Locator("div.monster-moves div.moves-inner-row > button.dropdown-button").All()
+	if err != nil {
+		return pokemon.Pokemon{}, err
+	}
+	for _, expandButton := range expandButtons {
+		if err := expandButton.Click(); err != nil {
+			return pokemon.Pokemon{}, fmt.Errorf("expanding moves: %w", err)
+		}
+	}
 
-	pokemons = append(pokemons, pokemon)
+	html, err := page.Content()
+	if err != nil {
+		return pokemon.Pokemon{}, err
+	}
+	return parsePokemon(strings.NewReader(html))
 }
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"PokemonNetCen/pokemon"
+)
+
+// queryFunc runs a query subcommand on the loaded pokedex with the
+// arguments left after its flags.
+type queryFunc func(dex *pokemon.Pokedex, args []string) error
+
+// queryCommands are the read-only subcommands over an existing pokedex
+// file. Each one adds its flags to the set and returns the function that
+// runs it once they are parsed.
+var queryCommands = map[string]func(flags *flag.FlagSet) queryFunc{
+	"search":  searchCommand,
+	"show":    withoutFlags(runShow),
+	"compare": withoutFlags(runCompare),
+	"weak":    withoutFlags(runWeak),
+	"serve":   serveCommand,
+}
+
+func withoutFlags(run queryFunc) func(flags *flag.FlagSet) queryFunc {
+	return func(flags *flag.FlagSet) queryFunc { return run }
+}
+
+// runQuery runs a query subcommand ("search", "show", ...). It returns
+// false if args do not start with one, so main falls back to crawling.
+func runQuery(args []string) bool {
+	command, ok := queryCommands[args[0]]
+	if !ok {
+		return false
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
+	dataDir := flags.String("data", "../data", "data directory holding "+pokemon.PokedexFile)
+	run := command(flags)
+	flags.Parse(args[1:])
+
+	dex, err := pokemon.LoadDir(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := run(dex, flags.Args()); err != nil {
+		log.Fatal(err)
+	}
+	return true
+}
+
+// lookup finds a species by name or national pokedex number.
+func lookup(dex *pokemon.Pokedex, key string) (pokemon.Pokemon, error) {
+	if number, err := strconv.Atoi(key); err == nil {
+		if p, ok := dex.ByNumber(number); ok {
+			return p, nil
+		}
+		return pokemon.Pokemon{}, fmt.Errorf("no species with pokedex number %d", number)
+	}
+	if p, ok := dex.ByName(key); ok {
+		return p, nil
+	}
+	return pokemon.Pokemon{}, fmt.Errorf("no species named %q", key)
+}
+
+// parseStatRanges reads "speed=90,hp=50" into a stat limit per name.
+func parseStatRanges(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := (pokemon.Stats{}).Value(name); !ok {
+			return nil, fmt.Errorf("unknown stat %q, use one of %s", name, strings.Join(pokemon.StatNames, ", "))
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit in %q", part)
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// searchOptions are the flags of the search command.
+type searchOptions struct {
+	name, element, egg, ability, min, max string
+	json                                  bool
+}
+
+func searchCommand(flags *flag.FlagSet) queryFunc {
+	var opts searchOptions
+	flags.StringVar(&opts.name, "name", "", "name prefix")
+	flags.StringVar(&opts.element, "element", "", "element, e.g. fire")
+	flags.StringVar(&opts.egg, "egg", "", "egg group, e.g. Monster")
+	flags.StringVar(&opts.ability, "ability", "", "ability, e.g. Overgrow")
+	flags.StringVar(&opts.min, "min", "", "lowest base stats, e.g. speed=90,total=500")
+	flags.StringVar(&opts.max, "max", "", "highest base stats, e.g. hp=50")
+	flags.BoolVar(&opts.json, "json", false, "print JSON instead of a table")
+	return func(dex *pokemon.Pokedex, args []string) error {
+		return runSearch(dex, opts)
+	}
+}
+
+func runSearch(dex *pokemon.Pokedex, opts searchOptions) error {
+	filter := pokemon.Filter{
+		NamePrefix: opts.name,
+		Element:    opts.element,
+		EggGroup:   opts.egg,
+		Ability:    opts.ability,
+	}
+	var err error
+	if filter.Min, err = parseStatRanges(opts.min); err != nil {
+		return err
+	}
+	if filter.Max, err = parseStatRanges(opts.max); err != nil {
+		return err
+	}
+
+	found := dex.Search(filter)
+	if opts.json {
+		return printJSON(found)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "#\tName\tElements\tHP\tAtk\tDef\tSpA\tSpD\tSpe\tTotal\t")
+	for _, p := range found {
+		s := p.Stats
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n", p.DexNumber, p.Name, strings.Join(p.Elements, "/"),
+			s.HP, s.Attack, s.Defense, s.SpAttack, s.SpDefense, s.Speed, s.Total())
+	}
+	w.Flush()
+	fmt.Printf("%d species\n", len(found))
+	return nil
+}
+
+func runShow(dex *pokemon.Pokedex, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: show <name or number>")
+	}
+	p, err := lookup(dex, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("#%03d %s", p.DexNumber, p.Name)
+	if p.Genus != "" {
+		fmt.Printf(", the %s", p.Genus)
+	}
+	fmt.Printf(" [%s]\n", strings.Join(p.Elements, "/"))
+	if p.FlavorText != "" {
+		fmt.Println(p.FlavorText)
+	}
+
+	s := p.Stats
+	fmt.Printf("HP %d  Attack %d  Defense %d  Sp. Atk %d  Sp. Def %d  Speed %d  (total %d)\n",
+		s.HP, s.Attack, s.Defense, s.SpAttack, s.SpDefense, s.Speed, s.Total())
+	pr := p.Profile
+	fmt.Printf("Height %g m  Weight %g kg  Catch rate %g  Hatch steps %d\n", pr.Height, pr.Weight, pr.CatchRate, pr.HatchSteps)
+	fmt.Printf("Egg groups: %s\nAbilities: %s\n", pr.EggGroup, pr.Abilities)
+	if pr.GenderRatio.MaleRatio+pr.GenderRatio.FemaleRatio == 0 {
+		fmt.Println("Genderless")
+	} else {
+		fmt.Printf("Gender: %g%% male, %g%% female\n", pr.GenderRatio.MaleRatio, pr.GenderRatio.FemaleRatio)
+	}
+
+	printMatchups(p)
+
+	for _, evolution := range p.Evolutions {
+		how := evolution.Condition
+		if evolution.Level > 0 {
+			how = fmt.Sprintf("at level %d", evolution.Level)
+		}
+		fmt.Printf("Evolution: %s -> %s %s\n", evolution.From, evolution.To, how)
+	}
+	if len(p.Evolutions) == 0 && p.NextEvolution != "" {
+		fmt.Printf("Evolution: %s -> %s", p.Name, p.NextEvolution)
+		if p.EvolutionLevel > 0 {
+			fmt.Printf(" at level %d", p.EvolutionLevel)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d moves\n", len(p.Moves))
+	return nil
+}
+
+func runWeak(dex *pokemon.Pokedex, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: weak <name or number>")
+	}
+	p, err := lookup(dex, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s [%s]\n", p.Name, strings.Join(p.Elements, "/"))
+	printMatchups(p)
+	return nil
+}
+
+func printMatchups(p pokemon.Pokemon) {
+	matchups := p.Matchups()
+	for _, group := range []struct {
+		label string
+		rows  []pokemon.DamageCoefficient
+	}{{"Weak to", matchups.Weak}, {"Resists", matchups.Resist}, {"Immune to", matchups.Immune}} {
+		if len(group.rows) == 0 {
+			continue
+		}
+		var parts []string
+		for _, row := range group.rows {
+			parts = append(parts, fmt.Sprintf("%s %gx", row.Element, row.Coefficient))
+		}
+		fmt.Printf("%s: %s\n", group.label, strings.Join(parts, ", "))
+	}
+}
+
+// comparison is two species side by side.
+type comparison struct {
+	Names         [2]string
+	Elements      [2][]string
+	Stats         map[string][2]int
+	Difference    map[string]int // First minus second
+	FirstVsSecond float64        // Best multiplier of the first species' elements against the second
+	SecondVsFirst float64
+}
+
+func compare(a, b pokemon.Pokemon) comparison {
+	c := comparison{
+		Names:         [2]string{a.Name, b.Name},
+		Elements:      [2][]string{a.Elements, b.Elements},
+		Stats:         make(map[string][2]int),
+		Difference:    make(map[string]int),
+		FirstVsSecond: bestMultiplier(a, b),
+		SecondVsFirst: bestMultiplier(b, a),
+	}
+	for _, name := range pokemon.StatNames {
+		first, _ := a.Stats.Value(name)
+		second, _ := b.Stats.Value(name)
+		c.Stats[name] = [2]int{first, second}
+		c.Difference[name] = first - second
+	}
+	return c
+}
+
+// bestMultiplier is the highest type multiplier any of the attacker's
+// elements gets against the defender.
+func bestMultiplier(attacker, defender pokemon.Pokemon) float64 {
+	chart := defender.TypeChart()
+	best := 0.0
+	for _, element := range attacker.Elements {
+		best = max(best, chart.Multiplier(element))
+	}
+	return best
+}
+
+func runCompare(dex *pokemon.Pokedex, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: compare <name or number> <name or number>")
+	}
+	a, err := lookup(dex, args[0])
+	if err != nil {
+		return err
+	}
+	b, err := lookup(dex, args[1])
+	if err != nil {
+		return err
+	}
+	c := compare(a, b)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "\t%s\t%s\tdiff\t\n", a.Name, b.Name)
+	fmt.Fprintf(w, "elements\t%s\t%s\t\t\n", strings.Join(a.Elements, "/"), strings.Join(b.Elements, "/"))
+	for _, name := range pokemon.StatNames {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%+d\t\n", name, c.Stats[name][0], c.Stats[name][1], c.Difference[name])
+	}
+	w.Flush()
+	fmt.Printf("%s's elements against %s: up to %gx\n", a.Name, b.Name, c.FirstVsSecond)
+	fmt.Printf("%s's elements against %s: up to %gx\n", b.Name, a.Name, c.SecondVsFirst)
+	return nil
+}
+
+func printJSON(v any) error {
+	js, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(js))
+	return nil
+}
+
+// serveCommand answers the same queries over HTTP as JSON:
+//
+//	GET /search?name=pi&element=electric&egg=Field&ability=Static&min_speed=90&max_hp=50
+//	GET /pokemon/{key}          key is a name or pokedex number
+//	GET /weaknesses/{key}
+//	GET /compare/{first}/{second}
+func serveCommand(flags *flag.FlagSet) queryFunc {
+	addr := flags.String("addr", "localhost:8082", "address to listen on")
+	return func(dex *pokemon.Pokedex, args []string) error {
+		log.Printf("serving %d species on http://%s", dex.Len(), *addr)
+		return http.ListenAndServe(*addr, queryHandler(dex))
+	}
+}
+
+// queryHandler routes the HTTP queries of serveCommand.
+func queryHandler(dex *pokemon.Pokedex) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := pokemon.Filter{
+			NamePrefix: query.Get("name"),
+			Element:    query.Get("element"),
+			EggGroup:   query.Get("egg"),
+			Ability:    query.Get("ability"),
+			Min:        make(map[string]int),
+			Max:        make(map[string]int),
+		}
+		for key, values := range query {
+			limits, stat, ok := filter.Min, strings.TrimPrefix(key, "min_"), strings.HasPrefix(key, "min_")
+			if !ok {
+				limits, stat, ok = filter.Max, strings.TrimPrefix(key, "max_"), strings.HasPrefix(key, "max_")
+			}
+			if !ok {
+				continue
+			}
+			parsed, err := parseStatRanges(stat + "=" + values[0])
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for name, limit := range parsed {
+				limits[name] = limit
+			}
+		}
+		writeJSON(w, dex.Search(filter))
+	})
+	mux.HandleFunc("GET /pokemon/{key}", func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := lookupHTTP(w, dex, r.PathValue("key")); ok {
+			writeJSON(w, p)
+		}
+	})
+	mux.HandleFunc("GET /weaknesses/{key}", func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := lookupHTTP(w, dex, r.PathValue("key")); ok {
+			writeJSON(w, p.Matchups())
+		}
+	})
+	mux.HandleFunc("GET /compare/{first}/{second}", func(w http.ResponseWriter, r *http.Request) {
+		a, ok := lookupHTTP(w, dex, r.PathValue("first"))
+		if !ok {
+			return
+		}
+		b, ok := lookupHTTP(w, dex, r.PathValue("second"))
+		if !ok {
+			return
+		}
+		writeJSON(w, compare(a, b))
+	})
+	return mux
+}
+
+func lookupHTTP(w http.ResponseWriter, dex *pokemon.Pokedex, key string) (pokemon.Pokemon, bool) {
+	p, err := lookup(dex, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return pokemon.Pokemon{}, false
+	}
+	return p, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("could not write response:", err)
+	}
+}
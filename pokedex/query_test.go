@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"PokemonNetCen/pokemon"
+)
+
+func TestSearchStatLimits(t *testing.T) {
+	species := func(name string, speed int) pokemon.Pokemon {
+		return pokemon.Pokemon{Name: name, Elements: []string{"normal"},
+			Stats: pokemon.Stats{HP: 50, Attack: 50, Defense: 50, Speed: speed, SpAttack: 50, SpDefense: 50}}
+	}
+	dex, err := pokemon.New([]pokemon.Pokemon{species("Slowpoke", 15), species("Jolteon", 130)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := queryHandler(dex)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Slowpoke", "Jolteon"}},
+		{"min_speed=90", []string{"Jolteon"}},
+		{"min_Speed=90", []string{"Jolteon"}},
+		{"max_SPEED=90", []string{"Slowpoke"}},
+		{"min_speed=200", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/search?"+test.query, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+			}
+
+			var found []pokemon.Pokemon
+			if err := json.NewDecoder(recorder.Body).Decode(&found); err != nil {
+				t.Fatal(err)
+			}
+			if found == nil {
+				t.Fatal("got null, want a list")
+			}
+			var names []string
+			for _, p := range found {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("got %v, want %v", names, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package pokemon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxCatchRate is the highest species catch rate.
+const MaxCatchRate = 255
+
+// Issue is a missing or suspicious value in a pokedex entry. Unlike the
+// problems Validate reports, an entry with issues is still usable.
+type Issue struct {
+	Name    string
+	Field   string
+	Problem string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s %s", i.Name, i.Field, i.Problem)
+}
+
+// Audit reports entry, profile and move values that a crawl most likely
+// got wrong, such as a catch rate of 0 or leftover markup in the egg groups.
+func Audit(entries []Pokemon) []Issue {
+	var issues []Issue
+	for _, p := range entries {
+		report := func(field, format string, args ...any) {
+			issues = append(issues, Issue{Name: p.Name, Field: field, Problem: fmt.Sprintf(format, args...)})
+		}
+		profile := p.Profile
+
+		if p.DexNumber == 0 {
+			report("DexNumber", "is missing")
+		}
+		if p.Genus == "" {
+			report("Genus", "is missing")
+		}
+		if p.FlavorText == "" {
+			report("FlavorText", "is missing")
+		}
+
+		switch {
+		case profile.CatchRate == 0:
+			report("CatchRate", "is missing")
+		case profile.CatchRate < 0 || profile.CatchRate > MaxCatchRate:
+			report("CatchRate", "is %g, outside 1-%d", profile.CatchRate, MaxCatchRate)
+		}
+		if profile.Height <= 0 {
+			report("Height", "is missing")
+		}
+		if profile.Weight <= 0 {
+			report("Weight", "is missing")
+		}
+		if profile.HatchSteps <= 0 {
+			report("HatchSteps", "is missing")
+		}
+		checkText(profile.EggGroup, "EggGroup", report)
+		checkText(profile.Abilities, "Abilities", report)
+
+		if len(p.Moves) == 0 {
+			report("Moves", "is missing")
+		}
+		for _, move := range p.Moves {
+			if move.PP <= 0 {
+				report("Moves", "%s has no PP", move.Name)
+			}
+		}
+
+		// Genderless species have both ratios at 0
+		if sum := profile.GenderRatio.MaleRatio + profile.GenderRatio.FemaleRatio; sum != 0 && sum != 100 {
+			report("GenderRatio", "adds up to %g%%", sum)
+		}
+	}
+	return issues
+}
+
+// checkText reports an empty list field or one with markup left in it.
+func checkText(value, field string, report func(field, format string, args ...any)) {
+	if value == "" {
+		report(field, "is missing")
+		return
+	}
+	if strings.ContainsAny(value, "[]{}()<>\"") {
+		report(field, "has stray characters: %q", value)
+	}
+}
@@ -0,0 +1,34 @@
+package pokemon
+
+// DefaultCatchRate stands in for species whose catch rate is missing from
+// the pokedex.
+const DefaultCatchRate = 45
+
+// Ball is a kind of Poké Ball. Bonus multiplies the species' catch rate.
+type Ball struct {
+	Name  string
+	Bonus float64
+}
+
+// Balls are the balls a player can throw, keyed by the name used in commands.
+var Balls = map[string]Ball{
+	"poke":  {Name: "Poké Ball", Bonus: 1},
+	"great": {Name: "Great Ball", Bonus: 1.5},
+	"ultra": {Name: "Ultra Ball", Bonus: 2},
+}
+
+// CatchChance is the probability, between 0 and 1, that the ball catches
+// the wild Pokémon while it has currentHP left. It follows the main-series
+// formula: the species' CatchRate (0-255) scaled by the ball's bonus and by
+// how much of its HP the Pokémon has lost.
+func CatchChance(wild *Pokemon, currentHP int, ball Ball) float64 {
+	rate := wild.Profile.CatchRate
+	if rate <= 0 {
+		rate = DefaultCatchRate
+	}
+
+	maxHP := wild.MaxHP()
+	hp := float64(min(max(currentHP, 1), maxHP))
+	a := (3*float64(maxHP) - 2*hp) * rate * ball.Bonus / (3 * float64(maxHP))
+	return min(a/255, 1)
+}
@@ -0,0 +1,111 @@
+package pokemon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// SchemaVersion is the version of the pokedex file format this package
+// reads and writes. Bump it whenever a change to Pokemon would make older
+// programs misread the file.
+const SchemaVersion = 1
+
+// PokedexFile is the name of the pokedex in the data directory.
+const PokedexFile = "pokedex.json"
+
+// DataPath returns the pokedex path in a data directory.
+func DataPath(dataDir string) string {
+	return filepath.Join(dataDir, PokedexFile)
+}
+
+// File is the on-disk form of the pokedex: the species list with the
+// schema version it was written for and a checksum of its content.
+type File struct {
+	SchemaVersion int
+	Checksum      string // Hex SHA-256 of the Pokemon list as written, without whitespace
+	Pokemon       []Pokemon
+}
+
+// NewFile wraps entries for writing, stamping the current schema version
+// and their checksum.
+func NewFile(entries []Pokemon) (File, error) {
+	js, err := json.Marshal(entries)
+	if err != nil {
+		return File{}, err
+	}
+	sum, err := checksum(js)
+	if err != nil {
+		return File{}, err
+	}
+	return File{SchemaVersion: SchemaVersion, Checksum: sum, Pokemon: entries}, nil
+}
+
+// Encode writes entries as an indented pokedex file.
+func Encode(w io.Writer, entries []Pokemon) error {
+	file, err := NewFile(entries)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(file)
+}
+
+// DecodeFile reads a pokedex file and checks its schema version and
+// checksum, but not the entries themselves (see Validate).
+func DecodeFile(r io.Reader) (File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return File{}, err
+	}
+	// Files from before the schema version are a bare list of species
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return File{}, errors.New("pokedex has no schema version; it predates version 1 and has to be converted or crawled again")
+	}
+
+	// The list stays raw until it is hashed, so keys Pokemon does not
+	// know are part of the checksum too
+	var raw struct {
+		SchemaVersion int
+		Checksum      string
+		Pokemon       json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return File{}, err
+	}
+
+	if raw.SchemaVersion != SchemaVersion {
+		return File{}, fmt.Errorf("pokedex schema version is %d, this program reads version %d", raw.SchemaVersion, SchemaVersion)
+	}
+	sum, err := checksum(raw.Pokemon)
+	if err != nil {
+		return File{}, err
+	}
+	if sum != raw.Checksum {
+		return File{}, fmt.Errorf("pokedex checksum mismatch: the file says %s but its content hashes to %s", raw.Checksum, sum)
+	}
+
+	file := File{SchemaVersion: raw.SchemaVersion, Checksum: raw.Checksum}
+	if err := json.Unmarshal(raw.Pokemon, &file.Pokemon); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
+
+// checksum hashes the species list as written with the whitespace between
+// tokens removed, so reformatting the file does not change it but editing,
+// adding or removing anything else does.
+func checksum(list []byte) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, list); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
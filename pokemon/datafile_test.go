@@ -0,0 +1,83 @@
+package pokemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testEntries = []Pokemon{
+	{DexNumber: 25, Name: "Pikachu", Elements: []string{"electric"},
+		Stats: Stats{HP: 35, Attack: 55, Defense: 40, SpAttack: 50, SpDefense: 50, Speed: 90}},
+	{DexNumber: 133, Name: "Eevee", Elements: []string{"normal"},
+		Stats: Stats{HP: 55, Attack: 55, Defense: 50, SpAttack: 45, SpDefense: 65, Speed: 55}},
+}
+
+// encodeTestFile writes testEntries as a pokedex file.
+func encodeTestFile(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, testEntries); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDecodeFile(t *testing.T) {
+	written := encodeTestFile(t)
+
+	// Reindented the way an editor might, which keeps the checksum
+	var reformatted bytes.Buffer
+	if err := json.Indent(&reformatted, []byte(written), "", "\t"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{"as written": written, "reformatted": reformatted.String()} {
+		t.Run(name, func(t *testing.T) {
+			file, err := DecodeFile(strings.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.SchemaVersion != SchemaVersion {
+				t.Errorf("schema version %d, want %d", file.SchemaVersion, SchemaVersion)
+			}
+			if !reflect.DeepEqual(file.Pokemon, testEntries) {
+				t.Errorf("got %+v, want %+v", file.Pokemon, testEntries)
+			}
+		})
+	}
+}
+
+func TestDecodeFileRejects(t *testing.T) {
+	written := encodeTestFile(t)
+	bareList, err := json.Marshal(testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"bare list", string(bareList), "no schema version"},
+		{"wrong version", strings.Replace(written, `"SchemaVersion": 1`, `"SchemaVersion": 2`, 1), "schema version is 2"},
+		{"no version", strings.Replace(written, `"SchemaVersion": 1,`, ``, 1), "schema version is 0"},
+		{"edited value", strings.Replace(written, `"Speed": 90`, `"Speed": 190`, 1), "checksum mismatch"},
+		{"added key", strings.Replace(written, `"Name": "Pikachu",`, `"Name": "Pikachu", "Shiny": true,`, 1), "checksum mismatch"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.data == written {
+				t.Fatal("the test did not change the file")
+			}
+			_, err := DecodeFile(strings.NewReader(test.data))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
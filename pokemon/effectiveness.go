@@ -0,0 +1,63 @@
+package pokemon
+
+// STAB is the same-type attack bonus applied when a Pokémon uses a move
+// that shares one of its elements.
+const STAB = 1.5
+
+// TypeChart maps an attacking element to the damage multiplier a species
+// takes from it. Elements missing from the chart deal normal damage.
+type TypeChart map[string]float64
+
+// TypeChart builds the Pokémon's chart from its "when attacked" rows,
+// ignoring the blank rows the crawler leaves behind.
+func (p *Pokemon) TypeChart() TypeChart {
+	chart := make(TypeChart, len(p.DamageWhenAttacked))
+	for _, row := range cleanDamageTable(p.DamageWhenAttacked) {
+		chart[row.Element] = row.Coefficient
+	}
+	return chart
+}
+
+// Multiplier returns the damage multiplier for a move of the given element.
+func (c TypeChart) Multiplier(element string) float64 {
+	if coefficient, ok := c[element]; ok && element != "" {
+		return coefficient
+	}
+	return 1
+}
+
+// SameTypeBonus returns STAB if the move's element matches either of the
+// attacker's elements, and 1 otherwise.
+func (p *Pokemon) SameTypeBonus(moveElement string) float64 {
+	if moveElement != "" && p.HasElement(moveElement) {
+		return STAB
+	}
+	return 1
+}
+
+// EffectivenessMessage describes a type multiplier the way the games do.
+// It returns an empty string for neutral hits.
+func EffectivenessMessage(multiplier float64) string {
+	switch {
+	case multiplier == 0:
+		return "It had no effect..."
+	case multiplier > 1:
+		return "It's super effective!"
+	case multiplier < 1:
+		return "It's not very effective..."
+	default:
+		return ""
+	}
+}
+
+// cleanDamageTable drops rows without an element; the pokedex page pads
+// its two-column table with empty cells that get crawled as coefficient 0.
+func cleanDamageTable(rows []DamageCoefficient) []DamageCoefficient {
+	clean := make([]DamageCoefficient, 0, len(rows))
+	for _, row := range rows {
+		if row.Element != "" {
+			clean = append(clean, row)
+		}
+	}
+	return clean
+}
@@ -0,0 +1,88 @@
+package pokemon
+
+// ExperienceForLevel is the total experience a Pokémon needs to reach a
+// level, using the medium-fast growth rate (level cubed).
+func ExperienceForLevel(level int) int {
+	level = ClampLevel(level)
+	if level == MinLevel {
+		return 0
+	}
+	return level * level * level
+}
+
+// ExperienceYield is the experience earned for defeating or catching a
+// Pokémon. The pokedex has no base experience column, so a quarter of
+// the species' base stat total stands in for it.
+func ExperienceYield(defeated *Pokemon) int {
+	baseExperience := defeated.Stats.Total() / 4
+	return max(baseExperience*ClampLevel(defeated.Level)/7, 1)
+}
+
+// Progress describes what happened when a Pokémon gained experience.
+type Progress struct {
+	Gained      int
+	OldLevel    int
+	NewLevel    int
+	EvolvedFrom []string // Species the Pokémon evolved from, in order
+}
+
+func (pr Progress) LeveledUp() bool {
+	return pr.NewLevel > pr.OldLevel
+}
+
+func (pr Progress) Evolved() bool {
+	return len(pr.EvolvedFrom) > 0
+}
+
+// GainExperience adds experience, raises the level for every threshold
+// crossed and evolves the Pokémon once it reaches its EvolutionLevel.
+// The pokedex supplies the evolved species; it may be nil to skip evolving.
+func (p *Pokemon) GainExperience(amount int, dex *Pokedex) Progress {
+	p.Level = ClampLevel(p.Level)
+	progress := Progress{Gained: amount, OldLevel: p.Level}
+
+	// Saves from before experience was tracked start at the level's threshold
+	p.Experience = max(p.Experience, ExperienceForLevel(p.Level)) + amount
+
+	for p.Level < MaxLevel && p.Experience >= ExperienceForLevel(p.Level+1) {
+		p.Level++
+	}
+	progress.NewLevel = p.Level
+
+	if dex != nil {
+		for {
+			from := p.Name
+			if !dex.Evolve(p) {
+				break
+			}
+			progress.EvolvedFrom = append(progress.EvolvedFrom, from)
+		}
+	}
+	return progress
+}
+
+// CanEvolve reports whether the Pokémon has reached its evolution level.
+// Species that evolve by other means have an EvolutionLevel of 0.
+func (p *Pokemon) CanEvolve() bool {
+	return p.NextEvolution != "" && p.EvolutionLevel > 0 && p.Level >= p.EvolutionLevel
+}
+
+// Evolve turns the Pokémon into its next evolution if it can evolve. The
+// species data (name, elements, base stats, profile, type chart, moves and
+// evolution) is replaced while InstanceID, Level, EV and Experience stay.
+func (d *Pokedex) Evolve(p *Pokemon) bool {
+	if !p.CanEvolve() {
+		return false
+	}
+	next, ok := d.ByName(p.NextEvolution)
+	if !ok {
+		return false
+	}
+
+	next.InstanceID = p.InstanceID
+	next.Level = p.Level
+	next.EV = p.EV
+	next.Experience = p.Experience
+	*p = next
+	return true
+}
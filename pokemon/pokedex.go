@@ -0,0 +1,210 @@
+package pokemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Pokedex is the read-only list of species loaded from pokedex.json.
+type Pokedex struct {
+	entries  []Pokemon
+	byName   map[string]int
+	byNumber map[int]int
+}
+
+// Load reads and validates a pokedex file. Files written for another
+// SchemaVersion or whose content does not match their checksum are rejected.
+func Load(filename string) (*Pokedex, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dex, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return dex, nil
+}
+
+// Decode reads a pokedex file from r and validates it.
+func Decode(r io.Reader) (*Pokedex, error) {
+	file, err := DecodeFile(r)
+	if err != nil {
+		return nil, err
+	}
+	return New(file.Pokemon)
+}
+
+// LoadDir loads the pokedex in a data directory.
+func LoadDir(dataDir string) (*Pokedex, error) {
+	return Load(DataPath(dataDir))
+}
+
+// New builds a pokedex from already decoded entries. Blank rows are
+// dropped from every species' damage table.
+func New(entries []Pokemon) (*Pokedex, error) {
+	if err := Validate(entries); err != nil {
+		return nil, err
+	}
+
+	dex := &Pokedex{
+		entries:  entries,
+		byName:   make(map[string]int, len(entries)),
+		byNumber: make(map[int]int, len(entries)),
+	}
+	for i, p := range entries {
+		dex.byName[strings.ToLower(p.Name)] = i
+		if p.DexNumber > 0 {
+			dex.byNumber[p.DexNumber] = i
+		}
+		dex.entries[i].DamageWhenAttacked = cleanDamageTable(p.DamageWhenAttacked)
+	}
+	return dex, nil
+}
+
+// Validate checks the invariants every program relies on: each species has
+// a unique name and pokedex number (if set), at least one element, positive
+// base stats, no negative damage coefficients, and evolves into a species
+// that is present in the list.
+func Validate(entries []Pokemon) error {
+	if len(entries) == 0 {
+		return errors.New("pokedex is empty")
+	}
+
+	names := make(map[string]bool, len(entries))
+	numbers := make(map[int]string, len(entries))
+	var errs []error
+	for i, p := range entries {
+		key := strings.ToLower(p.Name)
+		switch {
+		case p.Name == "":
+			errs = append(errs, fmt.Errorf("entry %d: missing name", i))
+			continue
+		case names[key]:
+			errs = append(errs, fmt.Errorf("%s: duplicate name", p.Name))
+		}
+		names[key] = true
+
+		switch other, seen := numbers[p.DexNumber]; {
+		case p.DexNumber < 0:
+			errs = append(errs, fmt.Errorf("%s: negative pokedex number %d", p.Name, p.DexNumber))
+		case p.DexNumber > 0 && seen:
+			errs = append(errs, fmt.Errorf("%s: pokedex number %d is also %s", p.Name, p.DexNumber, other))
+		case p.DexNumber > 0:
+			numbers[p.DexNumber] = p.Name
+		}
+
+		if len(p.Elements) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no elements", p.Name))
+		}
+		for _, row := range p.DamageWhenAttacked {
+			if row.Coefficient < 0 {
+				errs = append(errs, fmt.Errorf("%s: negative damage coefficient for %q", p.Name, row.Element))
+			}
+		}
+		s := p.Stats
+		if s.HP <= 0 || s.Attack <= 0 || s.Defense <= 0 || s.Speed <= 0 || s.SpAttack <= 0 || s.SpDefense <= 0 {
+			errs = append(errs, fmt.Errorf("%s: non-positive base stats %+v", p.Name, s))
+		}
+	}
+
+	for _, p := range entries {
+		if p.NextEvolution != "" && !names[strings.ToLower(p.NextEvolution)] {
+			errs = append(errs, fmt.Errorf("%s: unknown next evolution %q", p.Name, p.NextEvolution))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// All returns every species in pokedex order. The slice must not be modified.
+func (d *Pokedex) All() []Pokemon {
+	return d.entries
+}
+
+func (d *Pokedex) Len() int {
+	return len(d.entries)
+}
+
+// ByName looks a species up by name, ignoring case. The returned value is
+// a copy that the caller may modify freely.
+func (d *Pokedex) ByName(name string) (Pokemon, bool) {
+	i, ok := d.byName[strings.ToLower(name)]
+	if !ok {
+		return Pokemon{}, false
+	}
+	return d.entries[i].Clone(), true
+}
+
+// ByNumber returns a copy of the species with the given national pokedex
+// number. Entries crawled before numbers were recorded cannot be found.
+func (d *Pokedex) ByNumber(number int) (Pokemon, bool) {
+	i, ok := d.byNumber[number]
+	if !ok {
+		return Pokemon{}, false
+	}
+	return d.entries[i].Clone(), true
+}
+
+// ByElement returns every species that has the given element.
+func (d *Pokedex) ByElement(element string) []Pokemon {
+	element = strings.ToLower(element)
+	var result []Pokemon
+	for i := range d.entries {
+		if d.entries[i].HasElement(element) {
+			result = append(result, d.entries[i].Clone())
+		}
+	}
+	return result
+}
+
+// EvolutionChain returns the full chain the named species belongs to,
+// from its earliest pre-evolution to its final form.
+func (d *Pokedex) EvolutionChain(name string) []Pokemon {
+	current, ok := d.ByName(name)
+	if !ok {
+		return nil
+	}
+
+	// Walk back to the first stage
+	visited := map[string]bool{strings.ToLower(current.Name): true}
+	for {
+		prev, ok := d.previousEvolution(current.Name)
+		if !ok || visited[strings.ToLower(prev.Name)] {
+			break
+		}
+		visited[strings.ToLower(prev.Name)] = true
+		current = prev
+	}
+
+	chain := []Pokemon{current}
+	seen := map[string]bool{strings.ToLower(current.Name): true}
+	for current.NextEvolution != "" {
+		next, ok := d.ByName(current.NextEvolution)
+		if !ok || seen[strings.ToLower(next.Name)] {
+			break
+		}
+		seen[strings.ToLower(next.Name)] = true
+		chain = append(chain, next)
+		current = next
+	}
+	return chain
+}
+
+func (d *Pokedex) previousEvolution(name string) (Pokemon, bool) {
+	for i := range d.entries {
+		if strings.EqualFold(d.entries[i].NextEvolution, name) && !strings.EqualFold(d.entries[i].Name, name) {
+			return d.entries[i].Clone(), true
+		}
+	}
+	return Pokemon{}, false
+}
+
+// Random returns a copy of a random species.
+func (d *Pokedex) Random(intn func(int) int) Pokemon {
+	return d.entries[intn(len(d.entries))].Clone()
+}
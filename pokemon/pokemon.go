@@ -0,0 +1,144 @@
+// Package pokemon holds the data model shared by the pokedex crawler,
+// the pokeBat battle server and the pokecat overworld server, together
+// with helpers to load and query pokedex.json.
+package pokemon
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Stats are the six base stats of a species.
+type Stats struct {
+	HP        int `json:"HP"`
+	Attack    int `json:"Attack"`
+	Defense   int `json:"Defense"`
+	Speed     int `json:"Speed"`
+	SpAttack  int `json:"Sp_Attack"`
+	SpDefense int `json:"Sp_Defense"`
+}
+
+type GenderRatio struct {
+	MaleRatio   float64 `json:"MaleRatio"`
+	FemaleRatio float64 `json:"FemaleRatio"`
+}
+
+type Profile struct {
+	Height      float64     `json:"Height"`
+	Weight      float64     `json:"Weight"`
+	CatchRate   float64     `json:"CatchRate"`
+	GenderRatio GenderRatio `json:"GenderRatio"`
+	EggGroup    string      `json:"EggGroup"`
+	HatchSteps  int         `json:"HatchSteps"`
+	Abilities   string      `json:"Abilities"`
+}
+
+// DamageCoefficient is one row of the "when attacked" table: damage taken
+// from moves of Element is multiplied by Coefficient.
+type DamageCoefficient struct {
+	Element     string  `json:"Element"`
+	Coefficient float64 `json:"Coefficient"`
+}
+
+type Move struct {
+	Name        string `json:"Name"`
+	Element     string `json:"Element"`
+	Power       string `json:"Power"` // A number, or "—" for status moves
+	Acc         int    `json:"Acc"`
+	PP          int    `json:"PP"`
+	Description string `json:"Description"`
+	LearnMethod string `json:"LearnMethod,omitempty"` // One of the Learn* methods
+	LearnLevel  int    `json:"LearnLevel,omitempty"`  // Level a level-up move is learned at
+}
+
+// Ways a species learns a move.
+const (
+	LearnLevelUp = "level-up"
+	LearnMachine = "machine"
+	LearnEgg     = "egg"
+	LearnTutor   = "tutor"
+)
+
+// Evolution is one step of an evolution chain. Level is 0 for species that
+// evolve by other means, which Condition then describes.
+type Evolution struct {
+	From      string `json:"From"`
+	To        string `json:"To"`
+	Level     int    `json:"Level,omitempty"`
+	Condition string `json:"Condition,omitempty"` // e.g. "with a water stone"
+}
+
+// Pokemon is a pokedex entry. The same struct is stored in player saves,
+// where InstanceID, EV, Experience and Level describe that particular
+// Pokémon. Pokedex entries are reference data and are never modified.
+type Pokemon struct {
+	InstanceID         string              `json:"InstanceID,omitempty"`
+	DexNumber          int                 `json:"DexNumber,omitempty"` // National pokedex number
+	Name               string              `json:"Name"`
+	Genus              string              `json:"Genus,omitempty"` // Category, e.g. "Seed Pokémon"
+	Elements           []string            `json:"Elements"`
+	EV                 float64             `json:"EV"`
+	Stats              Stats               `json:"Stats"`
+	Profile            Profile             `json:"Profile"`
+	DamageWhenAttacked []DamageCoefficient `json:"DamegeWhenAttacked"`
+	EvolutionLevel     int                 `json:"EvolutionLevel"`
+	NextEvolution      string              `json:"NextEvolution"`
+	Evolutions         []Evolution         `json:"Evolutions,omitempty"` // The species' whole evolution chain
+	FlavorText         string              `json:"FlavorText,omitempty"`
+	Sprite             string              `json:"Sprite,omitempty"` // Image URL
+	Moves              []Move              `json:"Moves"`
+	Experience         int                 `json:"Experience"`
+	Level              int                 `json:"Level"`
+}
+
+// HasElement reports whether the Pokémon is of the given element.
+func (p *Pokemon) HasElement(element string) bool {
+	for _, e := range p.Elements {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy so that changes to the slices of the copy do
+// not leak back into the pokedex.
+func (p Pokemon) Clone() Pokemon {
+	p.Elements = cloneSlice(p.Elements)
+	p.DamageWhenAttacked = cloneSlice(p.DamageWhenAttacked)
+	p.Evolutions = cloneSlice(p.Evolutions)
+	p.Moves = cloneSlice(p.Moves)
+	return p
+}
+
+// cloneSlice copies s, keeping an empty slice empty rather than nil so it
+// still encodes as [] in JSON.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// KnownAt reports whether a Pokémon of the given level knows the move
+// without being taught it: level-up moves learned at or below that level.
+// Moves crawled without a learn method count as known.
+func (m Move) KnownAt(level int) bool {
+	switch m.LearnMethod {
+	case "":
+		return true
+	case LearnLevelUp:
+		return m.LearnLevel <= level
+	}
+	return false
+}
+
+// BasePower returns the move's power as a number. Status moves, whose
+// power is shown as "—" on the pokedex, have a base power of 0.
+func (m Move) BasePower() int {
+	power, err := strconv.Atoi(strings.TrimSpace(m.Power))
+	if err != nil || power < 0 {
+		return 0
+	}
+	return power
+}
@@ -0,0 +1,130 @@
+package pokemon
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// StatNames are the names Stats.Value accepts, in display order.
+var StatNames = []string{"hp", "attack", "defense", "sp_attack", "sp_defense", "speed", "total"}
+
+// Value returns a stat by name ("hp", "sp_attack", "total"...), ignoring case.
+func (s Stats) Value(name string) (int, bool) {
+	switch strings.ToLower(name) {
+	case "hp":
+		return s.HP, true
+	case "attack":
+		return s.Attack, true
+	case "defense":
+		return s.Defense, true
+	case "sp_attack":
+		return s.SpAttack, true
+	case "sp_defense":
+		return s.SpDefense, true
+	case "speed":
+		return s.Speed, true
+	case "total":
+		return s.Total(), true
+	}
+	return 0, false
+}
+
+// Total is the sum of all six stats.
+func (s Stats) Total() int {
+	return s.HP + s.Attack + s.Defense + s.Speed + s.SpAttack + s.SpDefense
+}
+
+// Filter selects species in Search. Empty fields match every species;
+// text fields ignore case.
+type Filter struct {
+	NamePrefix string
+	Element    string
+	EggGroup   string
+	Ability    string
+	Min        map[string]int // Lowest base stat, by StatNames name
+	Max        map[string]int // Highest base stat, by StatNames name
+}
+
+// Matches reports whether the species passes every part of the filter.
+func (f Filter) Matches(p *Pokemon) bool {
+	if !strings.HasPrefix(strings.ToLower(p.Name), strings.ToLower(f.NamePrefix)) {
+		return false
+	}
+	if f.Element != "" && !p.HasElement(strings.ToLower(f.Element)) {
+		return false
+	}
+	if f.EggGroup != "" && !listContains(p.Profile.EggGroup, f.EggGroup) {
+		return false
+	}
+	if f.Ability != "" && !listContains(p.Profile.Abilities, f.Ability) {
+		return false
+	}
+	for name, low := range f.Min {
+		if value, ok := p.Stats.Value(name); !ok || value < low {
+			return false
+		}
+	}
+	for name, high := range f.Max {
+		if value, ok := p.Stats.Value(name); !ok || value > high {
+			return false
+		}
+	}
+	return true
+}
+
+// listContains reports whether a comma separated profile list such as
+// "Monster, Grass" has the item.
+func listContains(list, item string) bool {
+	for _, entry := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(entry), strings.TrimSpace(item)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns copies of the species matching the filter, in pokedex order.
+// It is never nil, so no match encodes as an empty JSON list.
+func (d *Pokedex) Search(f Filter) []Pokemon {
+	found := []Pokemon{}
+	for i := range d.entries {
+		if f.Matches(&d.entries[i]) {
+			found = append(found, d.entries[i].Clone())
+		}
+	}
+	return found
+}
+
+// Matchups is a species' damage table split by how much damage it takes.
+type Matchups struct {
+	Weak   []DamageCoefficient `json:"Weak"`   // Multiplier above 1, highest first
+	Resist []DamageCoefficient `json:"Resist"` // Multiplier between 0 and 1, lowest first
+	Immune []DamageCoefficient `json:"Immune"` // Multiplier 0
+}
+
+// Matchups sorts the species' damage table into weaknesses, resistances
+// and immunities. Elements missing from the table do normal damage.
+func (p *Pokemon) Matchups() Matchups {
+	m := Matchups{Weak: []DamageCoefficient{}, Resist: []DamageCoefficient{}, Immune: []DamageCoefficient{}}
+	for _, row := range cleanDamageTable(p.DamageWhenAttacked) {
+		switch {
+		case row.Coefficient == 0:
+			m.Immune = append(m.Immune, row)
+		case row.Coefficient > 1:
+			m.Weak = append(m.Weak, row)
+		case row.Coefficient < 1:
+			m.Resist = append(m.Resist, row)
+		}
+	}
+
+	slices.SortFunc(m.Weak, func(a, b DamageCoefficient) int {
+		return cmp.Or(cmp.Compare(b.Coefficient, a.Coefficient), cmp.Compare(a.Element, b.Element))
+	})
+	byCoefficient := func(a, b DamageCoefficient) int {
+		return cmp.Or(cmp.Compare(a.Coefficient, b.Coefficient), cmp.Compare(a.Element, b.Element))
+	}
+	slices.SortFunc(m.Resist, byCoefficient)
+	slices.SortFunc(m.Immune, byCoefficient)
+	return m
+}
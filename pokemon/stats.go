@@ -0,0 +1,53 @@
+package pokemon
+
+const (
+	MinLevel = 1
+	MaxLevel = 100
+)
+
+// CalcStats computes the stats a Pokémon actually has at a level from its
+// species' base stats, using the main-series formula. EV is the effort
+// bonus earned in battle, applied as a percentage on top.
+func CalcStats(base Stats, level int, ev float64) Stats {
+	level = ClampLevel(level)
+	bonus := 1 + ev/100
+
+	calc := func(base int) int {
+		return int(float64(2*base*level/100+5) * bonus)
+	}
+
+	return Stats{
+		HP:        int(float64(2*base.HP*level/100+level+10) * bonus),
+		Attack:    calc(base.Attack),
+		Defense:   calc(base.Defense),
+		Speed:     calc(base.Speed),
+		SpAttack:  calc(base.SpAttack),
+		SpDefense: calc(base.SpDefense),
+	}
+}
+
+// ClampLevel keeps a level within MinLevel and MaxLevel.
+func ClampLevel(level int) int {
+	return min(max(level, MinLevel), MaxLevel)
+}
+
+// ActualStats returns the Pokémon's stats at its current Level and EV.
+// The Stats field itself always holds the species' base stats.
+func (p *Pokemon) ActualStats() Stats {
+	return CalcStats(p.Stats, p.Level, p.EV)
+}
+
+// MaxHP is the Pokémon's HP when fully healed.
+func (p *Pokemon) MaxHP() int {
+	return p.ActualStats().HP
+}
+
+// NewWild returns a wild Pokémon of the given species and level, with no
+// EV and the experience that level requires.
+func NewWild(species Pokemon, level int) Pokemon {
+	wild := species.Clone()
+	wild.Level = ClampLevel(level)
+	wild.EV = 0
+	wild.Experience = ExperienceForLevel(wild.Level)
+	return wild
+}